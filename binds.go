@@ -0,0 +1,106 @@
+package ora
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//SQL中引用的命名绑定变量（小写，去重），跳过字符串、带引号的标识符和注释
+func bindNames(s string) []string {
+	var names []string
+	var seen = make(map[string]bool)
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'' || s[i] == '"':
+			q := s[i]
+			for i++; i < len(s) && s[i] != q; i++ {
+			}
+		case strings.HasPrefix(s[i:], "--"):
+			for ; i < len(s) && s[i] != '\n'; i++ {
+			}
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return names
+			}
+			i = i + 2 + end + 1
+		case s[i] == ':' && i+1 < len(s) && unicode.IsLetter(rune(s[i+1])):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] == '$' || s[j] == '#' ||
+				unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			name := strings.ToLower(s[i+1 : j])
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			i = j - 1
+		}
+	}
+	return names
+}
+
+//绑定变量值：SQL的binds优先，其次插件级binds，最后为内置变量
+// - hostname       采集端主机名
+// - now            本次执行时间
+// - last_run_time  本SQL在该数据库（容器）上一次成功执行的时间，首次执行时为当前时间
+func (o *Ora) bindArgs(q *Query, now time.Time, last time.Time) ([]interface{}, error) {
+	names := bindNames(q.Sql)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var args []interface{}
+	for _, name := range names {
+		//REF CURSOR输出变量在执行时绑定
+		if q.plsql() && name == q.cursorName() {
+			continue
+		}
+
+		if v, ok := lookupBind(q.Binds, name); ok {
+			args = append(args, sql.Named(name, v))
+			continue
+		}
+		if v, ok := lookupBind(o.Binds, name); ok {
+			args = append(args, sql.Named(name, v))
+			continue
+		}
+
+		switch name {
+		case "hostname":
+			args = append(args, sql.Named(name, o.hostname))
+		case "now":
+			args = append(args, sql.Named(name, now))
+		case "last_run_time":
+			args = append(args, sql.Named(name, last))
+		default:
+			return nil, fmt.Errorf("bind :%s not set", name)
+		}
+	}
+	return args, nil
+}
+
+//SQL是否引用了指定绑定变量
+func usesBind(q *Query, name string) bool {
+	for _, n := range bindNames(q.Sql) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+//按名称查找绑定变量，不区分大小写
+func lookupBind(binds map[string]string, name string) (string, bool) {
+	for k, v := range binds {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,88 @@
+package ora
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+//熔断：SQL连续失败breaker_threshold次后在breaker_backoff内不再执行
+type breaker struct {
+	failures int
+	until    time.Time
+}
+
+//熔断标识：SQL与容器
+func breakerKey(c *container, q *Query) string {
+	if c == nil {
+		return q.key()
+	}
+	return q.key() + "\x00" + c.id
+}
+
+//SQL是否处于熔断期，熔断期内输出quarantined度量
+func (o *Ora) quarantined(acc telegraf.Accumulator, d *Database, c *container, q *Query, now time.Time) bool {
+	if o.BreakerThreshold <= 0 {
+		return false
+	}
+
+	//until由recordResult在锁内修改，在锁内读取
+	var until time.Time
+	d.mu.Lock()
+	if b, ok := d.breakers[breakerKey(c, q)]; ok {
+		until = b.until
+	}
+	d.mu.Unlock()
+	if !now.Before(until) {
+		return false
+	}
+
+	acc.AddFields(o.measurementName("ora_internal"), map[string]interface{}{
+		"quarantined":       int64(1),
+		"quarantined_until": until.Unix(),
+	}, o.queryTags(d, c, q))
+	return true
+}
+
+//记录执行结果，成功时清零，连续失败达到阀值时进入熔断期
+func (o *Ora) recordResult(d *Database, c *container, q *Query, err error) {
+	if o.BreakerThreshold <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := breakerKey(c, q)
+	if err == nil {
+		delete(d.breakers, key)
+		return
+	}
+
+	if d.breakers == nil {
+		d.breakers = make(map[string]*breaker)
+	}
+	b, ok := d.breakers[key]
+	if !ok {
+		b = &breaker{}
+		d.breakers[key] = b
+	}
+
+	b.failures++
+	if b.failures < o.BreakerThreshold {
+		return
+	}
+
+	backoff := o.BreakerBackoff.Duration
+	if backoff <= 0 {
+		backoff = defaultBreakerBackoff
+	}
+	b.failures = 0
+	b.until = time.Now().Add(backoff)
+	log.Printf("E! ora url=%s query=%s failed %d times in a row, skipped until %s",
+		d.u.redacted(), q.Name, o.BreakerThreshold, b.until.Format(time.RFC3339))
+}
+
+//默认熔断时长
+const defaultBreakerBackoff = 10 * time.Minute
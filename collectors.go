@@ -0,0 +1,1176 @@
+package ora
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+//内置采集项，由gather_*配置开启，与files中的SQL一同执行
+//实例级视图写作{g}v$xxx，并在选择列和分组前加{inst_id}，rac_mode时展开为gv$与inst_id
+
+//表空间使用率：永久/UNDO表空间按数据文件与空闲空间计算，临时表空间按排序段计算
+//max_bytes按自动扩展上限计算，free_bytes为距上限的剩余空间
+var tablespaceQueries = []*Query{
+	{
+		Name:        "tablespace",
+		Measurement: "ora_tablespace",
+		Sql: `SELECT d.tablespace_name, t.contents,
+       d.bytes allocated_bytes,
+       d.bytes - NVL(f.bytes, 0) used_bytes,
+       d.max_bytes - (d.bytes - NVL(f.bytes, 0)) free_bytes,
+       d.max_bytes,
+       ROUND((d.bytes - NVL(f.bytes, 0)) / d.max_bytes * 100, 2) used_percent
+  FROM (SELECT tablespace_name, SUM(bytes) bytes,
+               SUM(CASE WHEN autoextensible = 'YES' THEN GREATEST(bytes, maxbytes) ELSE bytes END) max_bytes
+          FROM dba_data_files
+         GROUP BY tablespace_name) d
+  JOIN dba_tablespaces t ON t.tablespace_name = d.tablespace_name
+  LEFT JOIN (SELECT tablespace_name, SUM(bytes) bytes
+               FROM dba_free_space
+              GROUP BY tablespace_name) f ON f.tablespace_name = d.tablespace_name
+UNION ALL
+SELECT d.tablespace_name, t.contents,
+       d.bytes allocated_bytes,
+       NVL(s.bytes, 0) used_bytes,
+       d.max_bytes - NVL(s.bytes, 0) free_bytes,
+       d.max_bytes,
+       ROUND(NVL(s.bytes, 0) / d.max_bytes * 100, 2) used_percent
+  FROM (SELECT tablespace_name, SUM(bytes) bytes,
+               SUM(CASE WHEN autoextensible = 'YES' THEN GREATEST(bytes, maxbytes) ELSE bytes END) max_bytes
+          FROM dba_temp_files
+         GROUP BY tablespace_name) d
+  JOIN dba_tablespaces t ON t.tablespace_name = d.tablespace_name
+  LEFT JOIN (SELECT ss.tablespace_name, SUM(ss.used_blocks * ts.block_size) bytes
+               FROM {g}v$sort_segment ss
+               JOIN dba_tablespaces ts ON ts.tablespace_name = ss.tablespace_name
+              GROUP BY ss.tablespace_name) s ON s.tablespace_name = d.tablespace_name`,
+	},
+}
+
+//等待事件：按等待类别汇总，输出增量
+var waitEventQueries = []*Query{
+	{
+		Name:        "wait_event",
+		Measurement: "ora_wait_event",
+		Sql: `SELECT {inst_id}wait_class, SUM(total_waits) total_waits, SUM(time_waited_micro) time_waited_micro
+  FROM {g}v$system_event
+ WHERE wait_class <> 'Idle'
+ GROUP BY {inst_id}wait_class`,
+		deltas: []string{"total_waits", "time_waited_micro"},
+	},
+}
+
+//会话：按状态、类型、等待类别、用户名、机器统计
+var sessionQueries = []*Query{
+	{
+		Name:        "sessions",
+		Measurement: "ora_sessions",
+		Sql: `SELECT {inst_id}status, type, wait_class,
+       NVL(username, 'BACKGROUND') username,
+       NVL(machine, 'UNKNOWN') machine,
+       COUNT(*) sessions,
+       SUM(CASE WHEN blocking_session IS NOT NULL THEN 1 ELSE 0 END) blocked_sessions
+  FROM {g}v$session
+ GROUP BY {inst_id}status, type, wait_class, NVL(username, 'BACKGROUND'), NVL(machine, 'UNKNOWN')`,
+	},
+}
+
+//阻塞：按阻塞链最终阻塞会话汇总被阻塞会话数与最长等待秒数，阻塞会话的SID/SERIAL#为标签
+//rac_mode时阻塞会话可能在其他实例上，需按实例号关联
+func (o *Ora) blockingQueries() []*Query {
+	join, inst, group := "b.sid = w.final_blocking_session", "", ""
+	if o.RacMode {
+		join = "b.inst_id = w.final_blocking_instance AND b.sid = w.final_blocking_session"
+		inst = "TO_CHAR(w.final_blocking_instance) blocking_inst_id, "
+		group = "w.final_blocking_instance, "
+	}
+
+	return []*Query{
+		{
+			Name:        "blocking_sessions",
+			Measurement: "ora_blocking",
+			MinVersion:  "11.2",
+			Sql: `SELECT ` + inst + `TO_CHAR(w.final_blocking_session) blocking_sid,
+       TO_CHAR(b.serial#) blocking_serial,
+       NVL(b.username, 'BACKGROUND') blocking_username,
+       COUNT(*) blocked_sessions,
+       MAX(w.seconds_in_wait) max_wait_seconds
+  FROM {g}v$session w
+  LEFT JOIN {g}v$session b ON ` + join + `
+ WHERE w.final_blocking_session IS NOT NULL
+ GROUP BY ` + group + `w.final_blocking_session, b.serial#, b.username`,
+		},
+		//无阻塞时也输出0，便于告警
+		{
+			Name:        "blocking_summary",
+			Measurement: "ora_blocking",
+			Sql: `SELECT COUNT(*) blocked_sessions, NVL(MAX(seconds_in_wait), 0) max_wait_seconds
+  FROM {g}v$session
+ WHERE blocking_session IS NOT NULL`,
+		},
+		{
+			Name:        "lock_waits",
+			Measurement: "ora_blocking",
+			Sql: `SELECT {inst_id}type lock_type, COUNT(*) waiting_locks, MAX(ctime) max_wait_seconds
+  FROM {g}v$lock
+ WHERE request > 0
+ GROUP BY {inst_id}type`,
+		},
+	}
+}
+
+//Top SQL：最近一小时执行过的SQL按本周期（差分后）耗时、CPU、逻辑读分别取前N条
+//sql_text截断为100字符并去掉换行，作为标签
+func (o *Ora) topSqlQuery() *Query {
+	return &Query{
+		Name:        "top_sql",
+		Measurement: "ora_top_sql",
+		Sql: `SELECT {inst_id}sql_id,
+       REPLACE(REPLACE(SUBSTR(sql_text, 1, 100), CHR(10), ' '), CHR(13), ' ') sql_text,
+       executions, elapsed_time elapsed_time_us, cpu_time cpu_time_us,
+       buffer_gets, disk_reads, rows_processed
+  FROM {g}v$sqlstats
+ WHERE last_active_time > SYSDATE - 1 / 24`,
+		Top:    o.GatherTopSql,
+		TopBy:  []string{"elapsed_time_us", "cpu_time_us", "buffer_gets"},
+		deltas: []string{"executions", "elapsed_time_us", "cpu_time_us", "buffer_gets", "disk_reads", "rows_processed"},
+	}
+}
+
+//临时表空间：按表空间与会话用户汇总临时段使用量
+var tempQueries = []*Query{
+	{
+		Name:        "temp_usage",
+		Measurement: "ora_temp",
+		Sql: `SELECT {inst_id}u.tablespace tablespace_name,
+       NVL(u.username, 'BACKGROUND') username,
+       u.segtype,
+       COUNT(DISTINCT u.session_addr) sessions,
+       SUM(u.blocks * t.block_size) used_bytes
+  FROM {g}v$tempseg_usage u
+  JOIN dba_tablespaces t ON t.tablespace_name = u.tablespace
+ GROUP BY {inst_id}u.tablespace, NVL(u.username, 'BACKGROUND'), u.segtype`,
+	},
+}
+
+//UNDO：当前统计周期（10分钟，各实例一行）的UNDO块数、事务数、最长查询秒数、
+//ORA-01555（ssolderrcnt）与空间不足（nospaceerrcnt）次数及未过期/已过期块数
+var undoQueries = []*Query{
+	{
+		Name:        "undo_stat",
+		Measurement: "ora_undo",
+		Sql: `SELECT {inst_id}undoblks undo_blocks, txncount transactions,
+       maxquerylen max_query_seconds, maxconcurrency max_concurrency,
+       tuned_undoretention tuned_undo_retention_seconds,
+       ssolderrcnt snapshot_too_old_errors, nospaceerrcnt no_space_errors,
+       activeblks active_blocks, unexpiredblks unexpired_blocks, expiredblks expired_blocks
+  FROM {g}v$undostat
+ WHERE begin_time > SYSDATE - 10 / 1440`,
+	},
+}
+
+//数据文件与临时文件I/O：按文件输出读写次数、字节数及读写耗时（readtim/writetim为厘秒）的本周期增量
+var fileIoQueries = []*Query{
+	{
+		Name:        "datafile_io",
+		Measurement: "ora_file_io",
+		Sql: `SELECT {inst_id}t.name tablespace_name, d.name file_name, 'datafile' file_type,
+       f.phyrds reads, f.phywrts writes,
+       f.phyblkrd * d.block_size read_bytes, f.phyblkwrt * d.block_size write_bytes,
+       f.readtim * 10 read_time_ms, f.writetim * 10 write_time_ms
+  FROM {g}v$filestat f
+  JOIN v$datafile d ON d.file# = f.file#
+  JOIN v$tablespace t ON t.ts# = d.ts#`,
+		deltas: []string{"reads", "writes", "read_bytes", "write_bytes", "read_time_ms", "write_time_ms"},
+	},
+	{
+		Name:        "tempfile_io",
+		Measurement: "ora_file_io",
+		Sql: `SELECT {inst_id}t.name tablespace_name, d.name file_name, 'tempfile' file_type,
+       f.phyrds reads, f.phywrts writes,
+       f.phyblkrd * d.block_size read_bytes, f.phyblkwrt * d.block_size write_bytes,
+       f.readtim * 10 read_time_ms, f.writetim * 10 write_time_ms
+  FROM {g}v$tempstat f
+  JOIN v$tempfile d ON d.file# = f.file#
+  JOIN v$tablespace t ON t.ts# = d.ts#`,
+		deltas: []string{"reads", "writes", "read_bytes", "write_bytes", "read_time_ms", "write_time_ms"},
+	},
+}
+
+//服务：v$servicemetric最近一分钟（group_id=6）的每次调用耗时、CPU及每秒调用数，
+//v$service_stats累计值按服务行转列并输出本周期增量（DB time、DB CPU为微秒）
+var serviceQueries = []*Query{
+	{
+		Name:        "service_metric",
+		Measurement: "ora_service",
+		Sql: `SELECT {inst_id}service_name,
+       elapsedpercall elapsed_per_call_us, cpupercall cpu_per_call_us, dbtimepercall db_time_per_call_us,
+       callspersec calls_per_sec, dbtimepersec db_time_per_sec
+  FROM {g}v$servicemetric
+ WHERE group_id = 6`,
+	},
+	{
+		Name:        "service_stats",
+		Measurement: "ora_service",
+		Sql: `SELECT {inst_id}service_name, stat_name, value
+  FROM {g}v$service_stats
+ WHERE stat_name IN ('DB time', 'DB CPU', 'user calls', 'execute count', 'session logical reads', 'physical reads', 'user commits')`,
+		PivotKey:   "stat_name",
+		PivotValue: "value",
+		deltas:     []string{"db_time", "db_cpu", "user_calls", "execute_count", "session_logical_reads", "physical_reads", "user_commits"},
+	},
+}
+
+//Resource Manager：按消费者组输出活动会话数、排队会话数，以及CPU等待次数、CPU等待毫秒数、
+//消耗CPU毫秒数等累计值的本周期增量
+var resourceManagerQueries = []*Query{
+	{
+		Name:        "consumer_group",
+		Measurement: "ora_resource_manager",
+		Sql: `SELECT {inst_id}name consumer_group,
+       active_sessions, execution_waiters, queue_length queued_sessions,
+       requests, cpu_waits, cpu_wait_time cpu_wait_time_ms, consumed_cpu_time consumed_cpu_time_ms, yields
+  FROM {g}v$rsrc_consumer_group`,
+		deltas: []string{"requests", "cpu_waits", "cpu_wait_time_ms", "consumed_cpu_time_ms", "yields"},
+	},
+}
+
+//库缓存与结果缓存：累计次数输出本周期增量，命中率为实例启动以来的累计值
+var cacheQueries = []*Query{
+	{
+		Name:        "library_cache",
+		Measurement: "ora_cache",
+		Sql: `SELECT {inst_id}namespace, gets, gethits, pins, pinhits, reloads, invalidations,
+       ROUND(gethitratio * 100, 2) get_hit_percent, ROUND(pinhitratio * 100, 2) pin_hit_percent
+  FROM {g}v$librarycache`,
+		deltas: []string{"gets", "gethits", "pins", "pinhits", "reloads", "invalidations"},
+	},
+	{
+		Name:        "result_cache",
+		Measurement: "ora_cache",
+		Sql: `SELECT {inst_id}name, value
+  FROM {g}v$result_cache_statistics`,
+		PivotKey:   "name",
+		PivotValue: "value",
+		deltas: []string{"create_count_success", "create_count_failure", "find_count", "find_copy_count",
+			"invalidation_count", "delete_count_invalid", "delete_count_valid"},
+	},
+}
+
+//操作系统统计：数据库所见的主机CPU数、负载、内存及CPU时间（厘秒，输出本周期增量）
+//采集端远程部署、无法读取数据库主机/proc时使用
+var osstatQuery = Query{
+	Name:        "osstat",
+	Measurement: "ora_osstat",
+	Sql: `SELECT {inst_id}LOWER(stat_name) stat_name, value
+  FROM {g}v$osstat
+ WHERE stat_name IN ('NUM_CPUS', 'NUM_CPU_CORES', 'NUM_CPU_SOCKETS', 'LOAD',
+                     'PHYSICAL_MEMORY_BYTES', 'FREE_MEMORY_BYTES',
+                     'BUSY_TIME', 'IDLE_TIME', 'USER_TIME', 'SYS_TIME', 'IOWAIT_TIME', 'NICE_TIME')`,
+	PivotKey:   "stat_name",
+	PivotValue: "value",
+	deltas:     []string{"busy_time", "idle_time", "user_time", "sys_time", "iowait_time", "nice_time"},
+}
+
+//活动会话采样：按等待类别（CPU表示在CPU上）、sql_id、module统计活动会话数
+//diagnostics_pack开启时读取上次成功采集以来的v$active_session_history，active_sessions为平均活动会话数；
+//未开启时只采样当前v$session，不使用需要Diagnostics Pack许可的视图
+var ashQueries = map[bool]*Query{
+	true: {
+		Name:        "ash",
+		Measurement: "ora_ash",
+		Sql: `WITH h AS (
+  SELECT {inst_id}sample_id, NVL(wait_class, 'CPU') wait_class, NVL(sql_id, 'NONE') sql_id, NVL(module, 'UNKNOWN') module
+    FROM {g}v$active_session_history
+   WHERE sample_time > :last_run_time)
+SELECT {inst_id}wait_class, sql_id, module, COUNT(*) samples,
+       ROUND(COUNT(*) / (SELECT COUNT(DISTINCT sample_id) FROM h), 2) active_sessions
+  FROM h
+ GROUP BY {inst_id}wait_class, sql_id, module`,
+	},
+	false: {
+		Name:        "ash",
+		Measurement: "ora_ash",
+		Sql: `SELECT {inst_id}CASE WHEN state = 'WAITING' THEN wait_class ELSE 'CPU' END wait_class,
+       NVL(sql_id, 'NONE') sql_id, NVL(module, 'UNKNOWN') module, COUNT(*) active_sessions
+  FROM {g}v$session
+ WHERE status = 'ACTIVE'
+   AND type = 'USER'
+   AND NOT (state = 'WAITING' AND wait_class = 'Idle')
+   AND sid <> SYS_CONTEXT('USERENV', 'SID')
+ GROUP BY {inst_id}CASE WHEN state = 'WAITING' THEN wait_class ELSE 'CPU' END, NVL(sql_id, 'NONE'), NVL(module, 'UNKNOWN')`,
+	},
+}
+
+//AWR常用统计项，gather_sysstat为空时使用
+var awrSysstatNames = []string{"user commits", "user calls", "execute count", "parse count (hard)",
+	"session logical reads", "physical reads", "redo size"}
+
+//AWR回填：读取上次成功采集以来结束的AWR快照，以快照结束时间作为度量值时间，
+//配合state_file可回填采集端停机期间的数据；sysstat按快照顺序输出相邻快照间的增量
+func (o *Ora) awrQueries() []*Query {
+	inst, where := "", "AND s.instance_number = SYS_CONTEXT('USERENV', 'INSTANCE')"
+	if o.RacMode {
+		inst, where = "s.instance_number inst_id, ", ""
+	}
+
+	names := o.GatherSysstat
+	if len(names) == 0 {
+		names = awrSysstatNames
+	}
+	var quoted, deltas []string
+	for _, n := range names {
+		quoted = append(quoted, "'"+strings.Replace(n, "'", "''", -1)+"'")
+		deltas = append(deltas, pivotFieldName(n))
+	}
+
+	if !o.DiagnosticsPack {
+		return o.statspackQueries(inst, strings.Join(quoted, ", "), deltas)
+	}
+
+	return []*Query{
+		{
+			Name:        "awr_sysmetric",
+			Measurement: "ora_awr_sysmetric",
+			TimeColumn:  "snap_time",
+			Sql: `SELECT ` + inst + `s.metric_name, s.metric_unit, s.average, s.maxval maximum, sn.end_interval_time snap_time
+  FROM dba_hist_sysmetric_summary s
+  JOIN dba_hist_snapshot sn
+    ON sn.snap_id = s.snap_id AND sn.dbid = s.dbid AND sn.instance_number = s.instance_number
+ WHERE s.dbid = (SELECT dbid FROM v$database)
+   AND sn.end_interval_time > :last_run_time ` + where + `
+ ORDER BY sn.end_interval_time`,
+			filter: o.sysmetricFilter,
+		},
+		{
+			Name:        "awr_sysstat",
+			Measurement: "ora_awr_sysstat",
+			TimeColumn:  "snap_time",
+			PivotKey:    "stat_name",
+			PivotValue:  "value",
+			Sql: `SELECT ` + inst + `s.stat_name, s.value, sn.end_interval_time snap_time
+  FROM dba_hist_sysstat s
+  JOIN dba_hist_snapshot sn
+    ON sn.snap_id = s.snap_id AND sn.dbid = s.dbid AND sn.instance_number = s.instance_number
+ WHERE s.dbid = (SELECT dbid FROM v$database)
+   AND sn.end_interval_time > :last_run_time ` + where + `
+   AND s.stat_name IN (` + strings.Join(quoted, ", ") + `)
+ ORDER BY sn.end_interval_time`,
+			deltas: deltas,
+		},
+	}
+}
+
+//Statspack：未购买Diagnostics Pack（如标准版）时代替AWR，从statspack_schema（默认PERFSTAT）的快照表读取
+//sysstat及非空闲等待事件，输出与AWR回填相同的度量值，等待事件按等待类别汇总并输出相邻快照间的增量
+func (o *Ora) statspackQueries(inst, names string, deltas []string) []*Query {
+	schema := o.StatspackSchema
+	if len(schema) == 0 {
+		schema = "PERFSTAT"
+	}
+	where, group := "AND s.instance_number = SYS_CONTEXT('USERENV', 'INSTANCE')", ""
+	if o.RacMode {
+		where, group = "", "s.instance_number, "
+	}
+
+	return []*Query{
+		{
+			Name:        "statspack_sysstat",
+			Measurement: "ora_awr_sysstat",
+			TimeColumn:  "snap_time",
+			PivotKey:    "stat_name",
+			PivotValue:  "value",
+			Sql: `SELECT ` + inst + `s.name stat_name, s.value, sn.snap_time
+  FROM ` + schema + `.stats$sysstat s
+  JOIN ` + schema + `.stats$snapshot sn
+    ON sn.snap_id = s.snap_id AND sn.dbid = s.dbid AND sn.instance_number = s.instance_number
+ WHERE s.dbid = (SELECT dbid FROM v$database)
+   AND sn.snap_time > :last_run_time ` + where + `
+   AND s.name IN (` + names + `)
+ ORDER BY sn.snap_time`,
+			deltas: deltas,
+		},
+		{
+			Name:        "statspack_wait_class",
+			Measurement: "ora_awr_wait_class",
+			TimeColumn:  "snap_time",
+			Sql: `SELECT ` + inst + `n.wait_class, SUM(s.total_waits) total_waits, SUM(s.time_waited_micro) time_waited_micro, sn.snap_time
+  FROM ` + schema + `.stats$system_event s
+  JOIN ` + schema + `.stats$snapshot sn
+    ON sn.snap_id = s.snap_id AND sn.dbid = s.dbid AND sn.instance_number = s.instance_number
+  JOIN v$event_name n ON n.event_id = s.event_id
+ WHERE s.dbid = (SELECT dbid FROM v$database)
+   AND sn.snap_time > :last_run_time ` + where + `
+   AND n.wait_class <> 'Idle'
+ GROUP BY ` + group + `n.wait_class, sn.snap_time
+ ORDER BY sn.snap_time`,
+			deltas: []string{"total_waits", "time_waited_micro"},
+		},
+	}
+}
+
+//Exadata：智能扫描、存储索引、闪存缓存相关统计的本周期增量及存储单元数，其他平台上这些视图和统计项不存在
+var exadataStats = []string{
+	"cell physical IO bytes eligible for predicate offload",
+	"cell physical IO interconnect bytes",
+	"cell physical IO interconnect bytes returned by smart scan",
+	"cell physical IO bytes saved by storage index",
+	"cell IO uncompressed bytes",
+	"cell flash cache read hits",
+	"physical read total IO requests",
+	"physical read total bytes",
+}
+
+func exadataQueries() []*Query {
+	var quoted, deltas []string
+	for _, n := range exadataStats {
+		quoted = append(quoted, "'"+n+"'")
+		deltas = append(deltas, pivotFieldName(n))
+	}
+
+	return []*Query{
+		{
+			Name:        "exadata_sysstat",
+			Measurement: "ora_exadata",
+			Sql: `SELECT {inst_id}name, value
+  FROM {g}v$sysstat
+ WHERE name IN (` + strings.Join(quoted, ", ") + `)`,
+			PivotKey:   "name",
+			PivotValue: "value",
+			deltas:     deltas,
+		},
+		{
+			Name:        "exadata_cells",
+			Measurement: "ora_exadata",
+			Sql:         `SELECT COUNT(*) cells FROM v$cell`,
+		},
+	}
+}
+
+//事务：按用户名与module统计未提交事务数、最长事务秒数、已用UNDO块数及正在回滚的事务数
+//rac_mode时按实例号关联会话，两个视图都有inst_id列，不能使用{inst_id}占位符
+func (o *Ora) transactionQueries() []*Query {
+	join, inst := "s.saddr = t.ses_addr", ""
+	if o.RacMode {
+		join, inst = "s.inst_id = t.inst_id AND s.saddr = t.ses_addr", "t.inst_id, "
+	}
+
+	return []*Query{
+		{
+			Name:        "transactions",
+			Measurement: "ora_transactions",
+			Sql: `SELECT ` + inst + `NVL(s.username, 'BACKGROUND') username, NVL(s.module, 'UNKNOWN') module,
+       COUNT(*) open_transactions,
+       ROUND(MAX((SYSDATE - t.start_date) * 86400)) max_age_seconds,
+       SUM(t.used_ublk) used_undo_blocks,
+       SUM(CASE WHEN BITAND(t.flag, 128) = 128 THEN 1 ELSE 0 END) rolling_back
+  FROM {g}v$transaction t
+  JOIN {g}v$session s ON ` + join + `
+ GROUP BY ` + inst + `NVL(s.username, 'BACKGROUND'), NVL(s.module, 'UNKNOWN')`,
+		},
+	}
+}
+
+//RAC互联：各实例global cache块接收数与接收时间（厘秒）、丢失/损坏块数的本周期增量，
+//及各实例对（inst_id、remote_inst_id）之间传输块数的本周期增量，直接使用gv$视图，与rac_mode无关
+var interconnectQueries = []*Query{
+	{
+		Name:        "gc_sysstat",
+		Measurement: "ora_interconnect",
+		Sql: `SELECT TO_CHAR(inst_id) inst_id, name, value
+  FROM gv$sysstat
+ WHERE name IN ('gc cr blocks received', 'gc cr block receive time',
+                'gc current blocks received', 'gc current block receive time',
+                'gc cr blocks served', 'gc current blocks served',
+                'gc blocks lost', 'gc blocks corrupt')`,
+		PivotKey:   "name",
+		PivotValue: "value",
+		deltas: []string{"gc_cr_blocks_received", "gc_cr_block_receive_time", "gc_current_blocks_received",
+			"gc_current_block_receive_time", "gc_cr_blocks_served", "gc_current_blocks_served", "gc_blocks_lost", "gc_blocks_corrupt"},
+	},
+	{
+		Name:        "gc_transfer",
+		Measurement: "ora_interconnect",
+		Sql: `SELECT TO_CHAR(inst_id) inst_id, TO_CHAR(instance) remote_inst_id,
+       SUM(cr_block) cr_blocks, SUM(current_block) current_blocks,
+       SUM(cr_busy + current_busy) busy_blocks, SUM(cr_congested + current_congested) congested_blocks
+  FROM gv$instance_cache_transfer
+ WHERE instance <> inst_id
+ GROUP BY inst_id, instance`,
+		deltas: []string{"cr_blocks", "current_blocks", "busy_blocks", "congested_blocks"},
+	},
+}
+
+//SGA/PGA内存，各SQL以func标签区分
+var memoryQueries = []*Query{
+	{
+		Name:        "sga",
+		Measurement: "ora_memory",
+		Sql:         `SELECT {inst_id}name, value bytes FROM {g}v$sga`,
+	},
+	{
+		Name:        "sgastat",
+		Measurement: "ora_memory",
+		Sql: `SELECT {inst_id}NVL(pool, name) pool, SUM(bytes) bytes
+  FROM {g}v$sgastat
+ GROUP BY {inst_id}NVL(pool, name)`,
+	},
+	{
+		Name:        "pgastat",
+		Measurement: "ora_memory",
+		Sql:         `SELECT {inst_id}name, NVL(unit, 'count') unit, value FROM {g}v$pgastat`,
+	},
+	{
+		Name:        "sga_target_advice",
+		Measurement: "ora_memory",
+		Sql: `SELECT {inst_id}TO_CHAR(sga_size_factor, 'FM990.099') size_factor,
+       sga_size * 1024 * 1024 size_bytes, estd_db_time, estd_physical_reads
+  FROM {g}v$sga_target_advice`,
+	},
+	{
+		Name:        "pga_target_advice",
+		Measurement: "ora_memory",
+		Sql: `SELECT {inst_id}TO_CHAR(pga_target_factor, 'FM990.099') size_factor,
+       pga_target_for_estimate size_bytes, estd_pga_cache_hit_percentage, estd_overalloc_count
+  FROM {g}v$pga_target_advice`,
+	},
+}
+
+//系统指标：group_id=3为短周期（15秒）指标
+var sysmetricQuery = Query{
+	Name:        "sysmetric",
+	Measurement: "ora_sysmetric",
+	Sql: `SELECT {inst_id}metric_name, metric_unit, value
+  FROM {g}v$sysmetric
+ WHERE group_id = 3`,
+}
+
+//按metric_name过滤系统指标
+func (o *Ora) sysmetricFilter(tags map[string]string) bool {
+	name := tags["metric_name"]
+	if o.sysmetricInclude != nil && !o.sysmetricInclude.Match(name) {
+		return false
+	}
+	if o.sysmetricExclude != nil && o.sysmetricExclude.Match(name) {
+		return false
+	}
+	return true
+}
+
+//统计值：gather_sysstat中的统计项按名称转为字段，每个实例一个度量值，输出本周期增量
+func (o *Ora) sysstatQuery() *Query {
+	var names, deltas []string
+	for _, n := range o.GatherSysstat {
+		names = append(names, "'"+strings.Replace(n, "'", "''", -1)+"'")
+		deltas = append(deltas, pivotFieldName(n))
+	}
+
+	return &Query{
+		Name:        "sysstat",
+		Measurement: "ora_sysstat",
+		Sql: `SELECT {inst_id}name, value
+  FROM {g}v$sysstat
+ WHERE name IN (` + strings.Join(names, ", ") + `)`,
+		PivotKey:   "name",
+		PivotValue: "value",
+		deltas:     deltas,
+	}
+}
+
+//数据库用户：账户状态及距密码过期天数，密码不过期的用户无days_until_expiry字段
+var userQuery = Query{
+	Name:        "users",
+	Measurement: "ora_users",
+	Interval:    internal.Duration{Duration: 5 * time.Minute},
+	Sql: `SELECT username, account_status,
+       CASE WHEN account_status LIKE '%LOCKED%' THEN 1 ELSE 0 END locked,
+       CASE WHEN account_status LIKE '%EXPIRED%' AND account_status NOT LIKE '%GRACE%' THEN 1 ELSE 0 END expired,
+       CASE WHEN account_status LIKE '%GRACE%' THEN 1 ELSE 0 END grace,
+       ROUND(expiry_date - SYSDATE, 2) days_until_expiry
+  FROM dba_users`,
+}
+
+//按用户名过滤
+func (o *Ora) userFilter(tags map[string]string) bool {
+	return o.users != nil && o.users.Match(tags["username"])
+}
+
+//GoldenGate：集成抽取进程（v$goldengate_capture）的状态与延迟秒数，
+//配置goldengate_schema时从心跳表视图GG_LAG读取各复制路径的延迟，process_name为路径（如EXT1 ==> PMP1 ==> REP1）
+func (o *Ora) goldengateQueries() []*Query {
+	queries := []*Query{
+		{
+			Name:        "goldengate_capture",
+			Measurement: "ora_goldengate",
+			Sql: `SELECT {inst_id}capture_name process_name, 'extract' process_type, state,
+       CASE WHEN state LIKE 'WAITING FOR%' OR state LIKE 'CAPTURING%' OR state LIKE 'ENQUEUING%'
+            OR state LIKE 'CREATING LCR%' OR state LIKE 'PAUSED%' THEN 1 ELSE 0 END running,
+       ROUND((SYSDATE - capture_message_create_time) * 86400) lag_seconds,
+       total_messages_captured
+  FROM {g}v$goldengate_capture`,
+		},
+	}
+	if len(o.GoldengateSchema) == 0 {
+		return queries
+	}
+
+	return append(queries, &Query{
+		Name:        "goldengate_heartbeat",
+		Measurement: "ora_goldengate",
+		Sql: `SELECT incoming_path process_name, 'replicat' process_type, remote_database, incoming_lag lag_seconds
+  FROM ` + o.GoldengateSchema + `.gg_lag
+ WHERE incoming_path IS NOT NULL`,
+	})
+}
+
+//Data Guard：主库上v$dataguard_stats和v$recovery_progress通常无数据
+var dataguardQueries = []*Query{
+	{
+		Name:        "dataguard_lag",
+		Measurement: "ora_dataguard",
+		Sql: `SELECT name,
+       EXTRACT(DAY FROM TO_DSINTERVAL(value)) * 86400
+       + EXTRACT(HOUR FROM TO_DSINTERVAL(value)) * 3600
+       + EXTRACT(MINUTE FROM TO_DSINTERVAL(value)) * 60
+       + EXTRACT(SECOND FROM TO_DSINTERVAL(value)) seconds
+  FROM v$dataguard_stats
+ WHERE name IN ('transport lag', 'apply lag', 'apply finish time')
+   AND value IS NOT NULL`,
+	},
+	{
+		Name:        "dataguard_apply_rate",
+		Measurement: "ora_dataguard",
+		Sql: `SELECT item, sofar kb_per_sec
+  FROM v$recovery_progress
+ WHERE item IN ('Active Apply Rate', 'Average Apply Rate')
+   AND start_time = (SELECT MAX(start_time) FROM v$recovery_progress)`,
+	},
+	{
+		Name:        "dataguard_dest",
+		Measurement: "ora_dataguard",
+		Sql: `SELECT TO_CHAR(dest_id) dest_id, dest_name, type, status,
+       NVL(gap_status, 'NONE') gap_status,
+       CASE WHEN gap_status IS NULL OR gap_status = 'NO GAP' THEN 0 ELSE 1 END gap,
+       CASE WHEN status = 'VALID' THEN 1 ELSE 0 END valid,
+       archived_seq# archived_seq, applied_seq# applied_seq
+  FROM v$archive_dest_status
+ WHERE status <> 'INACTIVE'`,
+	},
+}
+
+//RMAN备份：input_type区分DB FULL/DB INCR/ARCHIVELOG等，备份信息变化慢，降低执行频率
+var backupQueries = []*Query{
+	{
+		Name:        "rman_backup_job",
+		Measurement: "ora_backup",
+		Interval:    internal.Duration{Duration: 5 * time.Minute},
+		Sql: `SELECT input_type,
+       MAX(status) KEEP (DENSE_RANK LAST ORDER BY start_time) status,
+       ROUND((SYSDATE - MAX(end_time)) * 86400) last_backup_age_seconds,
+       ROUND((SYSDATE - MAX(CASE WHEN status LIKE 'COMPLETED%' THEN end_time END)) * 86400) last_success_age_seconds,
+       MAX(input_bytes) KEEP (DENSE_RANK LAST ORDER BY start_time) input_bytes,
+       MAX(output_bytes) KEEP (DENSE_RANK LAST ORDER BY start_time) output_bytes,
+       MAX(elapsed_seconds) KEEP (DENSE_RANK LAST ORDER BY start_time) elapsed_seconds,
+       SUM(CASE WHEN status LIKE 'FAILED%' THEN 1 ELSE 0 END) failed_jobs
+  FROM v$rman_backup_job_details
+ WHERE start_time > SYSDATE - 31
+ GROUP BY input_type`,
+	},
+	{
+		Name:        "backup_set",
+		Measurement: "ora_backup",
+		Interval:    internal.Duration{Duration: 5 * time.Minute},
+		Sql: `SELECT DECODE(backup_type, 'D', 'full', 'I', 'incremental', 'L', 'archivelog', backup_type) backup_type,
+       NVL(TO_CHAR(incremental_level), 'NONE') incremental_level,
+       ROUND((SYSDATE - MAX(completion_time)) * 86400) last_backup_age_seconds,
+       COUNT(*) backup_sets
+  FROM v$backup_set
+ GROUP BY backup_type, incremental_level`,
+	},
+}
+
+//无效对象：按属主和类型统计无效对象、编译错误及近一小时重新编译（DDL）的对象，数据字典查询较慢，降低执行频率
+var invalidObjectQueries = []*Query{
+	{
+		Name:        "invalid_objects",
+		Measurement: "ora_invalid_objects",
+		Interval:    internal.Duration{Duration: 5 * time.Minute},
+		Sql: `SELECT owner, object_type, COUNT(*) invalid_objects
+  FROM dba_objects
+ WHERE status = 'INVALID'
+ GROUP BY owner, object_type`,
+	},
+	//无无效对象时也输出0，便于告警
+	{
+		Name:        "invalid_objects_total",
+		Measurement: "ora_invalid_objects",
+		Interval:    internal.Duration{Duration: 5 * time.Minute},
+		Sql: `SELECT COUNT(*) invalid_objects
+  FROM dba_objects
+ WHERE status = 'INVALID'`,
+	},
+	{
+		Name:        "compile_errors",
+		Measurement: "ora_invalid_objects",
+		Interval:    internal.Duration{Duration: 5 * time.Minute},
+		Sql: `SELECT owner, type object_type, COUNT(DISTINCT name) objects_with_errors, COUNT(*) compile_errors
+  FROM dba_errors
+ WHERE attribute = 'ERROR'
+ GROUP BY owner, type`,
+	},
+	{
+		Name:        "recompiled_objects",
+		Measurement: "ora_invalid_objects",
+		Interval:    internal.Duration{Duration: 5 * time.Minute},
+		Sql: `SELECT owner, object_type, status, COUNT(*) recompiled_last_hour
+  FROM dba_objects
+ WHERE last_ddl_time > SYSDATE - 1 / 24
+ GROUP BY owner, object_type, status`,
+	},
+}
+
+//调度作业：DBMS_SCHEDULER作业的运行/失败次数、上次运行秒数及是否超期未运行（下次运行时间已过5分钟），
+//近一小时失败运行次数，以及DBMS_JOB作业的broken状态
+var schedulerQueries = []*Query{
+	{
+		Name:        "scheduler_jobs",
+		Measurement: "ora_scheduler",
+		Sql: `SELECT owner, job_name, state,
+       CASE WHEN enabled = 'TRUE' THEN 1 ELSE 0 END enabled,
+       run_count, failure_count,
+       EXTRACT(DAY FROM last_run_duration) * 86400
+       + EXTRACT(HOUR FROM last_run_duration) * 3600
+       + EXTRACT(MINUTE FROM last_run_duration) * 60
+       + EXTRACT(SECOND FROM last_run_duration) last_run_duration_seconds,
+       CASE WHEN enabled = 'TRUE' AND state = 'SCHEDULED'
+             AND next_run_date < SYSTIMESTAMP - INTERVAL '5' MINUTE THEN 1 ELSE 0 END overdue
+  FROM dba_scheduler_jobs`,
+	},
+	{
+		Name:        "scheduler_job_failures",
+		Measurement: "ora_scheduler",
+		Sql: `SELECT owner, job_name, COUNT(*) failed_runs_last_hour
+  FROM dba_scheduler_job_run_details
+ WHERE status = 'FAILED'
+   AND log_date > SYSTIMESTAMP - INTERVAL '1' HOUR
+ GROUP BY owner, job_name`,
+	},
+	{
+		Name:        "dbms_jobs",
+		Measurement: "ora_scheduler",
+		Sql: `SELECT schema_user owner, TO_CHAR(job) job_name,
+       CASE WHEN broken = 'Y' THEN 1 ELSE 0 END broken,
+       NVL(failures, 0) failure_count,
+       CASE WHEN broken = 'N' AND next_date < SYSDATE - 5 / 1440 THEN 1 ELSE 0 END overdue
+  FROM dba_jobs`,
+	},
+}
+
+//告警日志：上次成功采集以来的ORA-错误，按错误号统计次数，最近一条消息作为字段
+//首次采集从当前时间开始，不回溯历史告警
+var alertLogQueries = []*Query{
+	{
+		Name:         "alert_log_errors",
+		Measurement:  "ora_alert_log",
+		MinVersion:   "12.2",
+		FieldColumns: []string{"last_message"},
+		Sql: `SELECT {inst_id}REGEXP_SUBSTR(message_text, 'ORA-[0-9]+') error_code,
+       COUNT(*) errors,
+       MAX(SUBSTR(TRIM(REPLACE(REPLACE(message_text, CHR(10), ' '), CHR(13), ' ')), 1, 512))
+         KEEP (DENSE_RANK LAST ORDER BY originating_timestamp) last_message
+  FROM {g}v$diag_alert_ext
+ WHERE originating_timestamp > :last_run_time
+   AND component_id = 'rdbms'
+   AND message_text LIKE '%ORA-%'
+ GROUP BY {inst_id}REGEXP_SUBSTR(message_text, 'ORA-[0-9]+')`,
+	},
+}
+
+//审计：上次成功采集以来的登录失败、系统权限使用及被拒绝的操作，按用户名与客户端主机统计
+//unified为12c统一审计（unified_audit_trail），traditional为传统审计（dba_audit_trail）
+var auditQueries = map[string][]*Query{
+	"unified": {
+		{
+			Name:        "audit_failed_logons",
+			Measurement: "ora_audit",
+			MinVersion:  "12.1",
+			Sql: `SELECT NVL(dbusername, 'UNKNOWN') username, NVL(userhost, 'UNKNOWN') client_host, COUNT(*) failed_logons
+  FROM unified_audit_trail
+ WHERE event_timestamp > :last_run_time
+   AND action_name = 'LOGON'
+   AND return_code <> 0
+ GROUP BY dbusername, userhost`,
+		},
+		{
+			Name:        "audit_privilege_use",
+			Measurement: "ora_audit",
+			MinVersion:  "12.1",
+			Sql: `SELECT NVL(dbusername, 'UNKNOWN') username, NVL(userhost, 'UNKNOWN') client_host,
+       system_privilege_used privilege, COUNT(*) privilege_uses
+  FROM unified_audit_trail
+ WHERE event_timestamp > :last_run_time
+   AND system_privilege_used IS NOT NULL
+ GROUP BY dbusername, userhost, system_privilege_used`,
+		},
+		{
+			Name:        "audit_violations",
+			Measurement: "ora_audit",
+			MinVersion:  "12.1",
+			Sql: `SELECT NVL(dbusername, 'UNKNOWN') username, NVL(userhost, 'UNKNOWN') client_host,
+       NVL(unified_audit_policies, 'NONE') policy, COUNT(*) violations
+  FROM unified_audit_trail
+ WHERE event_timestamp > :last_run_time
+   AND action_name <> 'LOGON'
+   AND return_code <> 0
+ GROUP BY dbusername, userhost, unified_audit_policies`,
+		},
+	},
+	"traditional": {
+		{
+			Name:        "audit_failed_logons",
+			Measurement: "ora_audit",
+			Sql: `SELECT NVL(username, 'UNKNOWN') username, NVL(userhost, 'UNKNOWN') client_host, COUNT(*) failed_logons
+  FROM dba_audit_trail
+ WHERE timestamp > :last_run_time
+   AND action_name = 'LOGON'
+   AND returncode <> 0
+ GROUP BY username, userhost`,
+		},
+		{
+			Name:        "audit_privilege_use",
+			Measurement: "ora_audit",
+			Sql: `SELECT NVL(username, 'UNKNOWN') username, NVL(userhost, 'UNKNOWN') client_host,
+       priv_used privilege, COUNT(*) privilege_uses
+  FROM dba_audit_trail
+ WHERE timestamp > :last_run_time
+   AND priv_used IS NOT NULL
+ GROUP BY username, userhost, priv_used`,
+		},
+		{
+			Name:        "audit_violations",
+			Measurement: "ora_audit",
+			Sql: `SELECT NVL(username, 'UNKNOWN') username, NVL(userhost, 'UNKNOWN') client_host,
+       action_name policy, COUNT(*) violations
+  FROM dba_audit_trail
+ WHERE timestamp > :last_run_time
+   AND action_name <> 'LOGON'
+   AND returncode <> 0
+ GROUP BY username, userhost, action_name`,
+		},
+	},
+}
+
+//段：按属主与段类型汇总大小，segments_top大于0时另输出最大的N个段（分区汇总到段），
+//dba_segments查询较重，默认每小时执行一次，由segments_interval调整
+func (o *Ora) segmentQueries() []*Query {
+	interval := o.SegmentsInterval
+	if interval.Duration == 0 {
+		interval.Duration = time.Hour
+	}
+
+	queries := []*Query{
+		{
+			Name:        "segments",
+			Measurement: "ora_segments",
+			Interval:    interval,
+			Sql: `SELECT owner, segment_type, COUNT(*) segments, SUM(bytes) bytes, SUM(blocks) blocks
+  FROM dba_segments
+ GROUP BY owner, segment_type`,
+		},
+	}
+	if o.SegmentsTop <= 0 {
+		return queries
+	}
+
+	return append(queries, &Query{
+		Name:        "segments_top",
+		Measurement: "ora_segments",
+		Interval:    interval,
+		Sql: `SELECT owner, segment_name, segment_type, bytes
+  FROM (SELECT owner, segment_name, segment_type, SUM(bytes) bytes
+          FROM dba_segments
+         GROUP BY owner, segment_name, segment_type
+         ORDER BY SUM(bytes) DESC)
+ WHERE ROWNUM <= ` + strconv.Itoa(o.SegmentsTop),
+	})
+}
+
+//Redo：redo统计项及log file sync等等待事件的本周期增量，当前日志序列号及本周期日志切换次数（按线程）
+var redoQueries = []*Query{
+	{
+		Name:        "redo_sysstat",
+		Measurement: "ora_redo",
+		Sql: `SELECT {inst_id}name, value
+  FROM {g}v$sysstat
+ WHERE name IN ('redo size', 'redo writes', 'redo entries', 'redo log space requests',
+                'redo synch writes', 'redo synch time', 'user commits')`,
+		PivotKey:   "name",
+		PivotValue: "value",
+		deltas:     []string{"redo_size", "redo_writes", "redo_entries", "redo_log_space_requests", "redo_synch_writes", "redo_synch_time", "user_commits"},
+	},
+	{
+		Name:        "redo_wait_event",
+		Measurement: "ora_redo",
+		Sql: `SELECT {inst_id}event, total_waits, time_waited_micro
+  FROM {g}v$system_event
+ WHERE event IN ('log file sync', 'log file parallel write', 'log file switch completion',
+                 'log file switch (checkpoint incomplete)', 'log buffer space')`,
+		deltas: []string{"total_waits", "time_waited_micro"},
+	},
+	{
+		Name:        "redo_log",
+		Measurement: "ora_redo",
+		Sql: `SELECT TO_CHAR(thread#) thread, sequence# current_sequence, sequence# log_switches
+  FROM v$log
+ WHERE status = 'CURRENT'`,
+		deltas: []string{"log_switches"},
+	},
+}
+
+//归档日志与快速恢复区
+var recoveryQueries = []*Query{
+	{
+		Name:        "log_switch",
+		Measurement: "ora_recovery",
+		Sql: `SELECT COUNT(*) log_switches_last_hour
+  FROM v$log_history
+ WHERE first_time > SYSDATE - 1 / 24`,
+	},
+	{
+		Name:        "archived_log",
+		Measurement: "ora_recovery",
+		Sql: `SELECT COUNT(*) archived_logs_last_hour,
+       NVL(SUM(blocks * block_size), 0) archived_bytes_last_hour
+  FROM v$archived_log
+ WHERE completion_time > SYSDATE - 1 / 24
+   AND standby_dest = 'NO'
+   AND dest_id = (SELECT MIN(dest_id) FROM v$archived_log WHERE standby_dest = 'NO')`,
+	},
+	{
+		Name:        "recovery_file_dest",
+		Measurement: "ora_recovery",
+		Sql: `SELECT name, space_limit, space_used, space_reclaimable, number_of_files,
+       ROUND(space_used / NULLIF(space_limit, 0) * 100, 2) used_percent,
+       ROUND((space_used - space_reclaimable) / NULLIF(space_limit, 0) * 100, 2) used_percent_excl_reclaimable
+  FROM v$recovery_file_dest`,
+	},
+}
+
+//闪回：闪回日志大小、最早可闪回时间距今秒数及在快速恢复区中的占比，各还原点（尤其是担保还原点）的大小与存在秒数
+var flashbackQueries = []*Query{
+	{
+		Name:        "flashback_log",
+		Measurement: "ora_flashback",
+		Sql: `SELECT CASE WHEN d.flashback_on = 'YES' THEN 1 ELSE 0 END flashback_on,
+       l.flashback_size, l.estimated_flashback_size, l.retention_target retention_target_minutes,
+       ROUND((SYSDATE - l.oldest_flashback_time) * 86400) oldest_flashback_age_seconds,
+       u.percent_space_used fra_used_percent, u.percent_space_reclaimable fra_reclaimable_percent
+  FROM v$database d
+  LEFT JOIN v$flashback_database_log l ON 1 = 1
+  LEFT JOIN v$recovery_area_usage u ON u.file_type = 'FLASHBACK LOG'`,
+	},
+	{
+		Name:        "restore_point",
+		Measurement: "ora_flashback",
+		Sql: `SELECT name restore_point, guarantee_flashback_database guaranteed,
+       storage_size, ROUND((SYSDATE - CAST(time AS DATE)) * 86400) age_seconds
+  FROM v$restore_point`,
+	},
+	//无还原点时也输出0，便于对遗留的担保还原点告警
+	{
+		Name:        "restore_point_summary",
+		Measurement: "ora_flashback",
+		Sql: `SELECT COUNT(*) restore_points,
+       SUM(CASE WHEN guarantee_flashback_database = 'YES' THEN 1 ELSE 0 END) guaranteed_restore_points,
+       NVL(SUM(storage_size), 0) restore_point_bytes
+  FROM v$restore_point`,
+	},
+}
+
+//特性使用：dba_feature_usage_statistics中当前数据库最新版本下用过的特性，按常见付费选件归类，
+//该视图每周更新一次，每小时执行；选件归类只覆盖常见特性，以Oracle的许可文档为准
+const featureOption = `CASE
+         WHEN name LIKE 'Partitioning%' THEN 'Partitioning'
+         WHEN name IN ('AWR Report', 'Automatic Workload Repository', 'ADDM', 'Active Session History',
+                       'AWR Baseline', 'AWR Baseline Template', 'Diagnostic Pack') THEN 'Diagnostics Pack'
+         WHEN name IN ('SQL Tuning Advisor', 'SQL Access Advisor', 'Real-Time SQL Monitoring',
+                       'SQL Profile', 'SQL Tuning Set (user)', 'Tuning Pack') THEN 'Tuning Pack'
+         WHEN name LIKE 'Real Application Clusters%' THEN 'Real Application Clusters'
+         WHEN name LIKE 'Active Data Guard%' THEN 'Active Data Guard'
+         WHEN name LIKE 'In-Memory%' THEN 'Database In-Memory'
+         WHEN name IN ('Oracle Multitenant', 'Oracle Pluggable Databases') THEN 'Multitenant'
+         WHEN name LIKE '%Compression%' AND name NOT LIKE 'Backup BASIC%' THEN 'Advanced Compression'
+         WHEN name LIKE '%Encryption%' OR name LIKE 'Data Redaction%' THEN 'Advanced Security'
+         WHEN name IN ('Label Security', 'Oracle Label Security') THEN 'Label Security'
+         WHEN name LIKE '%Database Vault%' THEN 'Database Vault'
+         WHEN name LIKE 'Spatial%' THEN 'Spatial and Graph'
+         ELSE 'NONE'
+       END`
+
+const featureUsage = `FROM dba_feature_usage_statistics
+ WHERE dbid = (SELECT dbid FROM v$database)
+   AND version = (SELECT MAX(version) FROM dba_feature_usage_statistics WHERE dbid = (SELECT dbid FROM v$database))`
+
+var featureUsageQueries = []*Query{
+	{
+		Name:        "feature_usage",
+		Measurement: "ora_feature_usage",
+		Interval:    internal.Duration{Duration: time.Hour},
+		Sql: `SELECT name feature, ` + featureOption + ` license_option,
+       CASE WHEN currently_used = 'TRUE' THEN 1 ELSE 0 END currently_used,
+       detected_usages,
+       ROUND((SYSDATE - last_usage_date) * 86400) last_usage_age_seconds
+  ` + featureUsage + `
+   AND detected_usages > 0`,
+	},
+	{
+		Name:        "license_option",
+		Measurement: "ora_feature_usage",
+		Interval:    internal.Duration{Duration: time.Hour},
+		Sql: `SELECT license_option, SUM(currently_used) features_in_use, COUNT(*) features_used
+  FROM (SELECT ` + featureOption + ` license_option,
+               CASE WHEN currently_used = 'TRUE' THEN 1 ELSE 0 END currently_used
+          ` + featureUsage + `
+           AND detected_usages > 0)
+ WHERE license_option <> 'NONE'
+ GROUP BY license_option`,
+	},
+}
+
+//ASM磁盘组，_stat视图不触发磁盘发现
+var asmQueries = []*Query{
+	{
+		Name:        "asm_diskgroup",
+		Measurement: "ora_asm",
+		Sql: `SELECT g.name diskgroup, g.type redundancy, g.state,
+       g.total_mb, g.free_mb, g.usable_file_mb, g.required_mirror_free_mb, g.offline_disks,
+       (SELECT COUNT(*) FROM v$asm_disk_stat d WHERE d.group_number = g.group_number) disks,
+       ROUND((g.total_mb - g.free_mb) / NULLIF(g.total_mb, 0) * 100, 2) used_percent
+  FROM v$asm_diskgroup_stat g`,
+	},
+}
+
+//启用的内置采集SQL
+func (o *Ora) builtinQueries() []*Query {
+	var queries []*Query
+
+	if o.GatherTablespaces {
+		queries = append(queries, tablespaceQueries...)
+	}
+	if o.GatherWaitEvents {
+		queries = append(queries, waitEventQueries...)
+	}
+	if o.GatherSessions {
+		queries = append(queries, sessionQueries...)
+	}
+	if o.GatherBlocking {
+		queries = append(queries, o.blockingQueries()...)
+	}
+	if o.GatherTopSql > 0 {
+		queries = append(queries, o.topSqlQuery())
+	}
+	if o.GatherTemp {
+		queries = append(queries, tempQueries...)
+	}
+	if o.GatherUndo {
+		queries = append(queries, undoQueries...)
+	}
+	if o.GatherFileIo {
+		queries = append(queries, fileIoQueries...)
+	}
+	if o.GatherServices {
+		queries = append(queries, serviceQueries...)
+	}
+	if o.GatherResourceManager {
+		queries = append(queries, resourceManagerQueries...)
+	}
+	if o.GatherCache {
+		queries = append(queries, cacheQueries...)
+	}
+	if o.GatherOsstat {
+		queries = append(queries, &osstatQuery)
+	}
+	if o.GatherAsh {
+		queries = append(queries, ashQueries[o.DiagnosticsPack])
+	}
+	if o.GatherAwr {
+		queries = append(queries, o.awrQueries()...)
+	}
+	if o.GatherExadata {
+		queries = append(queries, exadataQueries()...)
+	}
+	if o.GatherTransactions {
+		queries = append(queries, o.transactionQueries()...)
+	}
+	if o.GatherInterconnect {
+		queries = append(queries, interconnectQueries...)
+	}
+	if o.GatherMemory {
+		queries = append(queries, memoryQueries...)
+	}
+	if o.GatherSysmetric {
+		q := sysmetricQuery
+		q.filter = o.sysmetricFilter
+		queries = append(queries, &q)
+	}
+	if len(o.GatherSysstat) > 0 {
+		queries = append(queries, o.sysstatQuery())
+	}
+	if len(o.GatherUsers) > 0 {
+		q := userQuery
+		q.filter = o.userFilter
+		queries = append(queries, &q)
+	}
+	if o.GatherGoldengate {
+		queries = append(queries, o.goldengateQueries()...)
+	}
+	if o.GatherDataguard {
+		queries = append(queries, dataguardQueries...)
+	}
+	if o.GatherBackups {
+		queries = append(queries, backupQueries...)
+	}
+	if o.GatherInvalidObjects {
+		queries = append(queries, invalidObjectQueries...)
+	}
+	if o.GatherScheduler {
+		queries = append(queries, schedulerQueries...)
+	}
+	if o.GatherAlertLog {
+		queries = append(queries, alertLogQueries...)
+	}
+	if len(o.GatherAudit) > 0 {
+		queries = append(queries, auditQueries[o.GatherAudit]...)
+	}
+	if o.GatherSegments {
+		queries = append(queries, o.segmentQueries()...)
+	}
+	if o.GatherRedo {
+		queries = append(queries, redoQueries...)
+	}
+	if o.GatherRecovery {
+		queries = append(queries, recoveryQueries...)
+	}
+	if o.GatherFlashback {
+		queries = append(queries, flashbackQueries...)
+	}
+	if o.GatherFeatureUsage {
+		queries = append(queries, featureUsageQueries...)
+	}
+	if o.GatherAsm {
+		queries = append(queries, asmQueries...)
+	}
+
+	//展开RAC占位符并按measurement_name命名，不修改内置SQL原值
+	for i, q := range queries {
+		c := *q
+		c.Sql = o.racSql(c.Sql)
+		c.Measurement = o.measurementName(c.Measurement)
+		queries[i] = &c
+	}
+
+	return queries
+}
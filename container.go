@@ -0,0 +1,123 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+//多租户容器
+type container struct {
+	id         string
+	name       string
+	needSwitch bool //是否需要ALTER SESSION SET CONTAINER切换
+}
+
+//列出需要采集的容器，未开启gather_pdbs或非CDB时返回nil
+// - 连接CDB$ROOT时返回过滤后的所有已打开容器（不含PDB$SEED）
+// - 直接连接PDB时只返回当前容器，不做切换
+func (o *Ora) containers(ctx context.Context, db *sql.DB) ([]*container, error) {
+	if !o.GatherPdbs {
+		return nil, nil
+	}
+
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+
+	//11g等非CDB数据库不支持CON_ID（ORA-02003）
+	var id, name string
+	err := db.QueryRowContext(ctx, `SELECT SYS_CONTEXT('USERENV', 'CON_ID'), SYS_CONTEXT('USERENV', 'CON_NAME') FROM dual`).Scan(&id, &name)
+	if err != nil && !strings.Contains(err.Error(), "ORA-02003") {
+		return nil, err
+	}
+	if err != nil || id == "0" || len(id) == 0 {
+		return nil, nil
+	}
+
+	if id != "1" {
+		return []*container{{id: id, name: name}}, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT TO_CHAR(con_id), name
+  FROM v$containers
+ WHERE con_id <> 2
+   AND open_mode IN ('READ WRITE', 'READ ONLY')
+ ORDER BY con_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cs []*container
+	for rows.Next() {
+		c := &container{}
+		if err := rows.Scan(&c.id, &c.name); err != nil {
+			return nil, err
+		}
+
+		if o.pdbInclude != nil && !o.pdbInclude.Match(c.name) {
+			continue
+		}
+		if o.pdbExclude != nil && o.pdbExclude.Match(c.name) {
+			continue
+		}
+
+		c.needSwitch = c.id != "1"
+		cs = append(cs, c)
+	}
+
+	return cs, rows.Err()
+}
+
+//在指定容器中执行SQL，c为nil时直接在连接池上执行
+//需要切换容器、开启kill_on_timeout或application_info时固定使用连接池中的一个会话
+func (o *Ora) gatherContainer(ctx context.Context, acc telegraf.Accumulator, d *Database, db *sql.DB, c *container, q *Query, st *queryStats) error {
+	var ctags map[string]string
+	if c != nil {
+		ctags = map[string]string{"con_id": c.id, "pdb_name": c.name}
+	}
+	if (c == nil || !c.needSwitch) && !o.KillOnTimeout && !o.ApplicationInfo {
+		return o.gatherInfo(ctx, acc, d, db, q, ctags, st)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	//超时后丢弃该连接并终止其会话，先归还连接，serialize时连接池只有一个会话
+	if o.KillOnTimeout || o.ApplicationInfo {
+		s, err := o.markSession(ctx, conn, q)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if s != nil && ctx.Err() == context.DeadlineExceeded {
+				conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+				conn.Close()
+				o.killSession(db, d, q, s)
+			}
+		}()
+	}
+
+	if c == nil || !c.needSwitch {
+		return o.gatherInfo(ctx, acc, d, conn, q, ctags, st)
+	}
+
+	if _, err := conn.ExecContext(ctx, `ALTER SESSION SET CONTAINER = "`+c.name+`"`); err != nil {
+		return err
+	}
+
+	//归还连接池前切回CDB$ROOT，失败则丢弃该连接
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `ALTER SESSION SET CONTAINER = CDB$ROOT`); err != nil {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return o.gatherInfo(ctx, acc, d, conn, q, ctags, st)
+}
@@ -0,0 +1,287 @@
+package ora
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/godror/godror"
+)
+
+//列值转为标签值
+func toTag(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case []byte:
+		return string(val), true
+	case int64:
+		return strconv.FormatInt(val, 10), true
+	case int32:
+		return strconv.FormatInt(int64(val), 10), true
+	case int:
+		return strconv.Itoa(val), true
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case godror.Number:
+		return val.String(), true
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05"), true
+	case time.Duration:
+		return strconv.FormatFloat(val.Seconds(), 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(val), true
+	}
+	return "", false
+}
+
+//列值转为字段值，能解析为数值的字符串转为float64
+func toField(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case string:
+		if n, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+			return n, true
+		}
+		return val, true
+	case []byte:
+		return toField(string(val))
+	case int64, int32, int, float32, float64, bool:
+		return val, true
+	case godror.Number:
+		n, err := strconv.ParseFloat(val.String(), 64)
+		return n, err == nil
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05"), true
+	case time.Duration:
+		return val.Seconds(), true
+	}
+	return nil, false
+}
+
+//按time_format转换DATE/TIMESTAMP字段值，未设置时返回false（作为标签）
+// - rfc3339   RFC3339格式字符串
+// - epoch     Unix秒
+// - epoch_ms  Unix毫秒
+func formatTime(t time.Time, format string) (interface{}, bool) {
+	switch format {
+	case "rfc3339":
+		return t.Format(time.RFC3339Nano), true
+	case "epoch":
+		return t.Unix(), true
+	case "epoch_ms":
+		return t.UnixNano() / int64(time.Millisecond), true
+	}
+	return nil, false
+}
+
+//时间格式是否支持
+func validTimeFormat(format string) bool {
+	switch format {
+	case "", "rfc3339", "epoch", "epoch_ms":
+		return true
+	}
+	return false
+}
+
+//大对象列默认最大字节数
+const defaultMaxLobLength = 4096
+
+//读取CLOB/LONG列，超过max字节时截断，max<0表示不限制
+func readLob(v interface{}, max int) (interface{}, error) {
+	var s string
+	switch val := v.(type) {
+	case *godror.Lob:
+		var r io.Reader = val
+		if max >= 0 {
+			r = io.LimitReader(val, int64(max))
+		}
+		bs, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		s = string(bs)
+	case string:
+		s = val
+	case []byte:
+		s = string(val)
+	default:
+		return v, nil
+	}
+
+	if max >= 0 && len(s) > max {
+		s = s[:max]
+	}
+	//截断处不能留下不完整的UTF-8字符
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s, nil
+}
+
+//time_column的值，并从标签和字段中去掉该列，非DATE/TIMESTAMP或为NULL时返回零值
+func (q *Query) rowTime(rowData map[string]*interface{}, tags map[string]string, fields map[string]interface{}) time.Time {
+	if len(q.TimeColumn) == 0 {
+		return time.Time{}
+	}
+
+	col := strings.ToLower(q.TimeColumn)
+	delete(tags, col)
+	delete(fields, col)
+	for k, v := range rowData {
+		if !strings.EqualFold(k, col) || v == nil {
+			continue
+		}
+		if t, ok := (*v).(time.Time); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+//列名是否在列表中，不区分大小写
+func hasColumn(cols []string, col string) bool {
+	for _, c := range cols {
+		if strings.EqualFold(c, col) {
+			return true
+		}
+	}
+	return false
+}
+
+//按field_types指定的类型转换字段值：int、float、bool、string
+func coerce(v interface{}, typ string) (interface{}, bool) {
+	switch typ {
+	case "string":
+		return toTag(v)
+	case "bool":
+		if s, ok := toTag(v); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b, true
+			}
+		}
+		if n, ok := toFloat(numeric(v)); ok {
+			return n != 0, true
+		}
+	case "int":
+		//字符串与NUMBER按文本解析，避免大整数经float64丢失精度
+		if s, ok := toTag(v); ok {
+			if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				return n, true
+			}
+		}
+		if n, ok := toFloat(numeric(v)); ok {
+			return int64(n), true
+		}
+	case "float":
+		if n, ok := toFloat(numeric(v)); ok {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+//转为数值，bool为1/0，无法转换时返回nil
+func numeric(v interface{}) interface{} {
+	if b, ok := v.(bool); ok {
+		if b {
+			return int64(1)
+		}
+		return int64(0)
+	}
+
+	f, ok := toField(v)
+	if !ok {
+		return nil
+	}
+	return f
+}
+
+//number_format=auto时，整数且在int64范围内的NUMBER转为int64
+func integerNumber(v interface{}) interface{} {
+	n, ok := v.(godror.Number)
+	if !ok {
+		return v
+	}
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		return i
+	}
+	return v
+}
+
+//true_values、false_values未配置时的默认值
+var (
+	defaultTrueValues  = []string{"YES", "Y", "TRUE", "ON", "OPEN", "ONLINE", "ENABLED", "VALID"}
+	defaultFalseValues = []string{"NO", "N", "FALSE", "OFF", "CLOSED", "OFFLINE", "DISABLED", "INVALID"}
+)
+
+//按true_values、false_values将文本转为bool，不区分大小写
+func (o *Ora) boolText(s string) (bool, bool) {
+	trues, falses := o.TrueValues, o.FalseValues
+	if len(trues) == 0 && len(falses) == 0 {
+		trues, falses = defaultTrueValues, defaultFalseValues
+	}
+
+	s = strings.TrimSpace(s)
+	for _, t := range trues {
+		if strings.EqualFold(t, s) {
+			return true, true
+		}
+	}
+	for _, f := range falses {
+		if strings.EqualFold(f, s) {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+//解析value_map：列 => "文本:值|文本:值"
+func parseValueMap(m map[string]string) (map[string]map[string]string, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	var vms = make(map[string]map[string]string)
+	for col, s := range m {
+		vm := make(map[string]string)
+		for _, kv := range strings.Split(s, "|") {
+			i := strings.LastIndex(kv, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("value_map %s `%s` format error", col, kv)
+			}
+			vm[strings.ToUpper(strings.TrimSpace(kv[:i]))] = strings.TrimSpace(kv[i+1:])
+		}
+		vms[strings.ToLower(col)] = vm
+	}
+	return vms, nil
+}
+
+//按value_map转换列值，文本不区分大小写，未配置映射的列返回false
+//已配置映射但未匹配的值原样返回
+func (q *Query) mapValue(col string, v interface{}) (interface{}, bool) {
+	vm, ok := q.valueMaps[col]
+	if !ok {
+		return v, false
+	}
+	if s, ok := toTag(v); ok {
+		if m, ok := vm[strings.ToUpper(strings.TrimSpace(s))]; ok {
+			return m, true
+		}
+	}
+	return v, true
+}
+
+//字段类型是否支持
+func validFieldType(typ string) bool {
+	switch typ {
+	case "int", "float", "bool", "string":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/godror/godror"
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+//是否为PL/SQL调用
+func (q *Query) plsql() bool {
+	s := strings.ToUpper(strings.TrimSpace(q.Sql))
+	return strings.HasPrefix(s, "BEGIN") || strings.HasPrefix(s, "DECLARE")
+}
+
+//返回REF CURSOR的绑定变量名
+func (q *Query) cursorName() string {
+	if len(q.Cursor) > 0 {
+		return strings.ToLower(q.Cursor)
+	}
+	return "cur"
+}
+
+//执行SQL得到结果集，PL/SQL调用读取其返回的REF CURSOR，此时conn应为固定的*sql.Conn
+func (o *Ora) query(ctx context.Context, d *Database, conn queryer, q *Query, args []interface{}) (*sql.Rows, error) {
+	if !q.plsql() {
+		args = append(args, o.fetchOptions(q)...)
+
+		//prepare_statements时在连接池上复用预编译语句，切换容器的专用会话除外
+		if db, ok := conn.(*sql.DB); ok && o.PrepareStatements {
+			st, err := d.stmt(ctx, db, q.Sql)
+			if err != nil {
+				return nil, err
+			}
+			return st.QueryContext(ctx, args...)
+		}
+		return conn.QueryContext(ctx, q.Sql, args...)
+	}
+
+	//SQL文件以;;分隔条目，补全END后的分号
+	sta := strings.TrimSpace(q.Sql)
+	if !strings.HasSuffix(sta, ";") {
+		sta = sta + ";"
+	}
+
+	if o.Driver == "go-ora" {
+		var cursor go_ora.RefCursor
+		args = append(args, sql.Named(q.cursorName(), sql.Out{Dest: &cursor}))
+		if _, err := conn.ExecContext(ctx, sta, args...); err != nil {
+			return nil, err
+		}
+		return go_ora.WrapRefCursor(ctx, conn, &cursor)
+	}
+
+	var rset driver.Rows
+	args = append(args, sql.Named(q.cursorName(), sql.Out{Dest: &rset}))
+	if _, err := conn.ExecContext(ctx, sta, args...); err != nil {
+		return nil, err
+	}
+	return godror.WrapRows(ctx, conn, rset)
+}
@@ -0,0 +1,29 @@
+package ora
+
+import (
+	_ "embed"
+)
+
+// 内置默认query pack
+//
+//go:embed default_queries.toml
+var defaultPack []byte
+
+//解析内置默认query pack，仅首次调用时解析
+func (o *Ora) defaultQueries() ([]*Query, error) {
+	if !o.UseDefaultQueries {
+		return nil, nil
+	}
+
+	if o.defaults == nil {
+		queries, err := parsePack(defaultPack)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range queries {
+			q.Measurement = o.measurementName(q.Measurement)
+		}
+		o.defaults = queries
+	}
+	return o.defaults, nil
+}
@@ -0,0 +1,133 @@
+package ora
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+//累计列的转换方式
+const (
+	transformDelta = "delta" //本周期增量
+	transformRate  = "rate"  //每秒增量
+)
+
+func validTransform(t string) bool {
+	switch t {
+	case transformDelta, transformRate:
+		return true
+	}
+	return false
+}
+
+//上次采集的累计值
+type counter struct {
+	value float64
+	time  time.Time
+}
+
+//累计列及其转换方式，内置采集项的deltas按delta处理
+func (q *Query) transforms() map[string]string {
+	if len(q.deltas) == 0 && len(q.Transforms) == 0 {
+		return nil
+	}
+	m := make(map[string]string)
+	for _, col := range q.deltas {
+		m[col] = transformDelta
+	}
+	for col, t := range q.Transforms {
+		m[strings.ToLower(col)] = t
+	}
+	return m
+}
+
+//累计值差分：保存上次采集的值，输出本周期增量或每秒增量
+//上次的值按SQL名称与SQL文本（q.key()）及标签区分，同名SQL或重新加载后SQL变化时不共用
+//首次采集或计数器变小（实例重启）时只记录不输出该字段
+func (d *Database) delta(q *Query, now time.Time, tags map[string]string, fields map[string]interface{}) {
+	cols := q.transforms()
+	if len(cols) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.last == nil {
+		d.last = make(map[string]counter)
+	}
+
+	prefix := q.key() + "\x00" + tagKey(tags)
+	for col, t := range cols {
+		v, ok := toFloat(fields[col])
+		if !ok {
+			continue
+		}
+
+		k := prefix + "\x00" + col
+		last, seen := d.last[k]
+		d.last[k] = counter{value: v, time: now}
+		if !seen || v < last.value {
+			delete(fields, col)
+			continue
+		}
+
+		if t == transformRate {
+			secs := now.Sub(last.time).Seconds()
+			if secs <= 0 {
+				delete(fields, col)
+				continue
+			}
+			fields[col] = (v - last.value) / secs
+			continue
+		}
+		fields[col] = v - last.value
+	}
+}
+
+//长时间未更新的累计值，如已老化的SQL
+const counterTTL = time.Hour
+
+//清理超过counterTTL未更新的累计值，避免top_sql等高基数采集项占用的内存持续增长
+func (d *Database) expireCounters(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, c := range d.last {
+		if now.Sub(c.time) > counterTTL {
+			delete(d.last, k)
+		}
+	}
+}
+
+//按标签名排序后拼接，作为同一序列的标识
+func tagKey(tags map[string]string) string {
+	var keys []string
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var s []string
+	for _, k := range keys {
+		s = append(s, k+"="+tags[k])
+	}
+	return strings.Join(s, ",")
+}
+
+//数值字段转换为float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,109 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+//dry_run：不执行SQL，只解析结果集的列并报告每列作为标签还是字段
+//在外层包一层 WHERE 1 = 0，数据库只做解析和优化，不返回数据；PL/SQL调用需要执行，跳过
+func (o *Ora) dryRun(ctx context.Context, acc telegraf.Accumulator, d *Database, db *sql.DB) {
+	now := time.Now()
+	for _, q := range o.queries {
+		if !d.compatible(q) {
+			continue
+		}
+
+		tags := o.urlTags(d)
+		tags["query"] = q.Name
+		fields := map[string]interface{}{"ok": 1}
+
+		if q.plsql() {
+			fields["error"] = "PL/SQL call not described"
+			log.Printf("I! ora dry_run host=%s instance=%s tag=%s PL/SQL call not described", d.u.host, d.u.instance, q.Name)
+			acc.AddFields(o.measurementName("ora_dry_run"), fields, tags)
+			continue
+		}
+
+		cols, err := o.describe(ctx, d, db, q, now)
+		if err != nil {
+			fields["ok"] = 0
+			fields["error"] = err.Error()
+			log.Printf("E! ora dry_run host=%s instance=%s tag=%s error , %s", d.u.host, d.u.instance, q.Name, err)
+			acc.AddFields(o.measurementName("ora_dry_run"), fields, tags)
+			continue
+		}
+
+		var mapping = make(map[string][]string)
+		var report []string
+		for _, ct := range cols {
+			col := strings.ToLower(ct.Name())
+			kind := q.columnKind(col, ct.DatabaseTypeName(), o.timeFormat(q))
+			mapping[kind] = append(mapping[kind], col)
+			report = append(report, fmt.Sprintf("%s=%s(%s)", col, kind, ct.DatabaseTypeName()))
+		}
+		for _, kind := range []string{"tag", "field", "ignore", "pivot_key", "pivot_value", "time"} {
+			if len(mapping[kind]) > 0 {
+				fields[kind+"s"] = strings.Join(mapping[kind], ",")
+			}
+		}
+
+		log.Printf("I! ora dry_run host=%s instance=%s tag=%s %s", d.u.host, d.u.instance, q.Name, strings.Join(report, " "))
+		acc.AddFields(o.measurementName("ora_dry_run"), fields, tags)
+	}
+}
+
+//只解析不取数，返回结果集的列
+func (o *Ora) describe(ctx context.Context, d *Database, db *sql.DB, q *Query, now time.Time) ([]*sql.ColumnType, error) {
+	args, err := o.bindArgs(q, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM ("+q.Sql+") WHERE 1 = 0", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.ColumnTypes()
+}
+
+//按列配置与数据库类型推断列的去向，与parseRow对非空值的处理一致
+func (q *Query) columnKind(col, typ, timeFormat string) string {
+	switch {
+	case hasColumn(q.IgnoreColumns, col):
+		return "ignore"
+	case strings.EqualFold(q.PivotKey, col):
+		return "pivot_key"
+	case strings.EqualFold(q.PivotValue, col):
+		return "pivot_value"
+	case strings.EqualFold(q.TimeColumn, col):
+		return "time"
+	case len(q.fieldType(col)) > 0 || q.valueMaps[col] != nil:
+		return "field"
+	case hasColumn(q.TagColumns, col):
+		return "tag"
+	case hasColumn(q.FieldColumns, col):
+		return "field"
+	}
+
+	switch strings.ToUpper(typ) {
+	case "VARCHAR2", "NVARCHAR2", "VARCHAR", "CHAR", "NCHAR", "ROWID", "UROWID":
+		return "tag"
+	case "DATE", "TIMESTAMP", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE":
+		if len(timeFormat) > 0 {
+			return "field"
+		}
+		return "tag"
+	}
+	return "field"
+}
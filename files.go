@@ -0,0 +1,310 @@
+package ora
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+//展开files配置：通配符按匹配结果，目录递归查找*.sql及*.toml，结果排序并去重
+//未匹配到文件的通配符忽略，普通路径原样返回以便报告读取错误
+func sqlFiles(patterns []string) ([]string, error) {
+	var files []string
+	var seen = make(map[string]bool)
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+
+	for _, p := range patterns {
+		if isRemote(p) {
+			add(p)
+			continue
+		}
+
+		var paths = []string{p}
+		if strings.ContainsAny(p, "*?[") {
+			ms, err := filepath.Glob(p)
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(ms)
+			paths = ms
+		}
+
+		for _, path := range paths {
+			fi, err := os.Stat(path)
+			if err != nil || !fi.IsDir() {
+				add(path)
+				continue
+			}
+
+			var fs []string
+			err = filepath.Walk(path, func(f string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() && (strings.EqualFold(filepath.Ext(f), ".sql") || isPack(f)) {
+					fs = append(fs, f)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(fs)
+			for _, f := range fs {
+				add(f)
+			}
+		}
+	}
+	return files, nil
+}
+
+//已加载的SQL文件
+type sqlFile struct {
+	modTime time.Time
+	size    int64
+	queries []*Query
+
+	//远程文件
+	fetched      time.Time
+	etag         string
+	lastModified string
+}
+
+//加载SQL文件，内容未变化时直接返回缓存
+//重新加载或失败时输出日志及ora_internal指标，失败时保留上次的内容，初始化时acc为nil
+func (o *Ora) loadFile(acc telegraf.Accumulator, file string) (*sqlFile, error) {
+	old := o.files[file]
+
+	var f *sqlFile
+	var err error
+	if isRemote(file) {
+		f, err = o.fetchFile(file, old)
+	} else {
+		f, err = statFile(file, old)
+	}
+	if err != nil {
+		if old != nil && acc != nil {
+			log.Printf("E! ora reload SQL file %s error , %s", file, err)
+			acc.AddFields(o.measurementName("ora_internal"), map[string]interface{}{"file_reload_errors": 1}, map[string]string{"file": file})
+		}
+		return old, err
+	}
+
+	if f != old {
+		f.queries = o.readOnlyQueries(file, f.queries)
+	}
+
+	if old != nil && f != old && acc != nil {
+		log.Printf("I! ora reloaded SQL file %s, %d queries", file, len(f.queries))
+		acc.AddFields(o.measurementName("ora_internal"), map[string]interface{}{"file_reloads": 1}, map[string]string{"file": file})
+	}
+	return f, nil
+}
+
+//读取本地文件，修改时间与大小未变化时返回old
+func statFile(file string, old *sqlFile) (*sqlFile, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil && fi.ModTime().Equal(old.modTime) && fi.Size() == old.size {
+		return old, nil
+	}
+
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := parseContent(file, bs)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlFile{modTime: fi.ModTime(), size: fi.Size(), queries: queries}, nil
+}
+
+//按扩展名解析SQL文件或query pack
+func parseContent(file string, bs []byte) ([]*Query, error) {
+	if !isPack(file) {
+		return parseFile(string(bs)), nil
+	}
+
+	queries, err := parsePack(bs)
+	if err != nil {
+		return nil, fmt.Errorf("ora query pack %s error , %s", file, err)
+	}
+	return queries, nil
+}
+
+//去除SQL文件中的注释，字符串与带引号的标识符中的内容保持不变
+// - -- 行注释与 /* */ 块注释，/*+ */ 优化器提示保留
+// - 行首或同一行;;之后以#开头的为注释：#name::sql形式的忽略条目去掉到其;;为止，其余去掉整行
+// - 未结束的块注释记录错误日志，之后的内容保留
+func stripComments(s string) string {
+	var b strings.Builder
+	lineStart := true
+	afterSep := false //同一行中;;之后只有空白
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (lineStart || afterSep) && (c == ' ' || c == '\t'):
+			b.WriteByte(c)
+			continue
+		case (lineStart || afterSep) && c == '#':
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			if strings.Contains(s[i:i+end], "::") {
+				//忽略的条目可跨多行
+				if sep := strings.Index(s[i:], ";;"); sep >= 0 {
+					i = i + sep + 1
+					lineStart, afterSep = false, true
+				} else {
+					i = len(s)
+				}
+				continue
+			}
+			i = i + end - 1
+			continue
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteString(s[i : i+end+2])
+			i = i + end + 1
+		case strings.HasPrefix(s[i:], "--"):
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(s[i:], "/*") && !strings.HasPrefix(s[i:], "/*+"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				log.Printf("E! SQL file unterminated /* comment `%s`", firstLine(s[i:]))
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteByte(' ')
+			i = i + 2 + end + 1
+		default:
+			b.WriteByte(c)
+		}
+		lineStart = c == '\n'
+		afterSep = c == ';' && i > 0 && s[i-1] == ';'
+	}
+	return b.String()
+}
+
+//文本的第一行，用于日志
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return strings.TrimSpace(s)
+}
+
+//SQL文件头部的默认值，以@开头的行，位于第一条SQL之前：
+//
+//	@measurement ora_custom
+//	@tags team=dba,env=prod
+//
+//@tags也接受条目属性tags的写法 team:dba|env:prod
+type fileDefaults struct {
+	measurement string
+	tags        map[string]string
+}
+
+//解析并去掉文件头部，content为去掉注释后的内容，头部之前只允许空行
+func parseHeader(content string) (*fileDefaults, string) {
+	var fd fileDefaults
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		s := strings.TrimSpace(line)
+		if len(s) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(s, "@") {
+			break
+		}
+		lines[i] = ""
+
+		kv := strings.SplitN(s[1:], " ", 2)
+		v := ""
+		if len(kv) == 2 {
+			v = strings.TrimSpace(kv[1])
+		}
+		switch kv[0] {
+		case "measurement":
+			fd.measurement = v
+		case "tags":
+			m, err := parseHeaderTags(v)
+			if err != nil {
+				log.Printf("E! SQL file header @tags %s", err)
+				continue
+			}
+			fd.tags = m
+		default:
+			log.Printf("I! SQL file header `%s` not support", s)
+		}
+	}
+	return &fd, strings.Join(lines, "\n")
+}
+
+//解析@tags：k=v或k:v，以,或|分隔
+func parseHeaderTags(v string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, kv := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '|' }) {
+		i := strings.IndexAny(kv, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("`%s` format error", strings.TrimSpace(kv))
+		}
+		m[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+	}
+	if len(m) == 0 {
+		return nil, fmt.Errorf("`%s` format error", v)
+	}
+	return m, nil
+}
+
+//为文件中的SQL补充默认的度量值名称和静态标签，SQL自身的设置优先
+func (fd *fileDefaults) apply(queries []*Query) {
+	for _, q := range queries {
+		if len(q.Measurement) == 0 {
+			q.Measurement = fd.measurement
+		}
+		for k, v := range fd.tags {
+			if _, ok := q.Tags[k]; ok {
+				continue
+			}
+			if q.Tags == nil {
+				q.Tags = make(map[string]string)
+			}
+			q.Tags[k] = v
+		}
+	}
+}
+
+//已加载的文件，按路径排序
+func cachedFiles(files map[string]*sqlFile) []string {
+	var names []string
+	for f := range files {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,82 @@
+package ora
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		measurement string
+		tags        map[string]string
+	}{
+		{
+			name:        "equals form",
+			content:     "@measurement ora_custom\n@tags team=dba\na::SELECT 1 FROM dual;;",
+			measurement: "ora_custom",
+			tags:        map[string]string{"team": "dba"},
+		},
+		{
+			name:    "equals form comma separated",
+			content: "@tags team=dba, env=prod\na::SELECT 1 FROM dual;;",
+			tags:    map[string]string{"team": "dba", "env": "prod"},
+		},
+		{
+			name:    "equals form pipe separated",
+			content: "@tags team=dba|env=prod\na::SELECT 1 FROM dual;;",
+			tags:    map[string]string{"team": "dba", "env": "prod"},
+		},
+		{
+			name:    "attribute form",
+			content: "@tags team:dba|env:prod\na::SELECT 1 FROM dual;;",
+			tags:    map[string]string{"team": "dba", "env": "prod"},
+		},
+		{
+			name:    "malformed tags dropped",
+			content: "@tags team\na::SELECT 1 FROM dual;;",
+		},
+		{
+			name:    "header after first query ignored",
+			content: "a::SELECT 1 FROM dual;;\n@tags team=dba",
+		},
+	}
+
+	for _, tt := range tests {
+		fd, _ := parseHeader(tt.content)
+		if fd.measurement != tt.measurement {
+			t.Errorf("%s: measurement = %q, want %q", tt.name, fd.measurement, tt.measurement)
+		}
+		if len(fd.tags) != 0 || len(tt.tags) != 0 {
+			if !reflect.DeepEqual(fd.tags, tt.tags) {
+				t.Errorf("%s: tags = %v, want %v", tt.name, fd.tags, tt.tags)
+			}
+		}
+	}
+}
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"line comment", "a::SELECT 1 -- one\nFROM dual;;", "a::SELECT 1 \nFROM dual;;"},
+		{"block comment", "a::SELECT /* x */1 FROM dual;;", "a::SELECT  1 FROM dual;;"},
+		{"hint kept", "a::SELECT /*+ FULL(t) */ 1 FROM t;;", "a::SELECT /*+ FULL(t) */ 1 FROM t;;"},
+		{"markers in string kept", "a::SELECT '--x', '/*y*/' FROM dual;;", "a::SELECT '--x', '/*y*/' FROM dual;;"},
+		{"hash comment line", "# sessions\na::SELECT 1 FROM dual;;", "\na::SELECT 1 FROM dual;;"},
+		{"hash column kept", "a::SELECT obj# FROM dual;;", "a::SELECT obj# FROM dual;;"},
+		{"commented entry", "# old[x=1]::select 'it''s';;\nb::SELECT 2 FROM dual;;", "\nb::SELECT 2 FROM dual;;"},
+		{"commented multi-line entry", "#old::SELECT 1\n  FROM dual;;\nb::SELECT 2 FROM dual;;", "\nb::SELECT 2 FROM dual;;"},
+		{"hash after separator", "a::SELECT 1 FROM dual;; # don't\nb::SELECT 2 FROM dual;;", "a::SELECT 1 FROM dual;; \nb::SELECT 2 FROM dual;;"},
+		{"unterminated block comment", "a::SELECT 1 FROM dual;;\n/* open\nb::SELECT 2 FROM dual;;", "a::SELECT 1 FROM dual;;\n/* open\nb::SELECT 2 FROM dual;;"},
+	}
+
+	for _, tt := range tests {
+		if got := stripComments(tt.in); got != tt.want {
+			t.Errorf("%s: stripComments(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
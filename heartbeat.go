@@ -0,0 +1,39 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+//可用性：连接失败时up=0，连接成功时输出连接（含Ping）毫秒数及打开模式、实例状态
+//MOUNT状态下v$database仍可查询，查询失败时只输出up与connect_ms
+//identity_tags开启但从未查询到标识时不输出，避免同一数据库出现URL标签与标识标签两组序列
+func (o *Ora) heartbeat(ctx context.Context, acc telegraf.Accumulator, d *Database, db *sql.DB, elapsed time.Duration) {
+	if o.IdentityTags && d.identity == nil {
+		return
+	}
+
+	var fields = map[string]interface{}{"up": 0}
+	if db != nil {
+		fields["up"] = 1
+		fields["connect_ms"] = float64(elapsed) / float64(time.Millisecond)
+
+		qctx, cancel := o.queryContext(ctx)
+		defer cancel()
+
+		var openMode, status string
+		err := db.QueryRowContext(qctx, `SELECT d.open_mode, i.database_status FROM v$database d, v$instance i`).Scan(&openMode, &status)
+		if err != nil {
+			log.Printf("W! ora up host=%s instance=%s status error , %s", d.u.host, d.u.instance, err)
+		} else {
+			fields["open_mode"] = openMode
+			fields["database_status"] = status
+		}
+	}
+
+	acc.AddFields(o.measurementName("ora_up"), fields, o.urlTags(d))
+}
@@ -0,0 +1,40 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+)
+
+//数据库标识，identity_tags开启时连接后查询一次，重建连接后重新查询
+type identity struct {
+	dbid         string
+	dbUniqueName string
+	instanceName string
+	version      string
+	role         string
+}
+
+//查询v$database与v$instance
+func (o *Ora) identity(ctx context.Context, db *sql.DB) (*identity, error) {
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+
+	var id identity
+	err := db.QueryRowContext(ctx, `SELECT TO_CHAR(d.dbid), d.db_unique_name, i.instance_name, i.version, d.database_role
+FROM v$database d, v$instance i`).Scan(&id.dbid, &id.dbUniqueName, &id.instanceName, &id.version, &id.role)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+//标识标签，替代URL标签
+func (id *identity) tags() map[string]string {
+	return map[string]string{
+		"dbid":           id.dbid,
+		"db_unique_name": id.dbUniqueName,
+		"instance_name":  id.instanceName,
+		"version":        id.version,
+		"database_role":  id.role,
+	}
+}
@@ -0,0 +1,39 @@
+package ora
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+//监听探测的连接超时
+const listenerTimeout = 5 * time.Second
+
+//监听探测：TCP连接URL中的监听地址，输出是否可达及连接毫秒数，用于区分监听故障与数据库故障
+//TNS别名或连接描述符中无法确定地址时不探测
+func (o *Ora) probeListener(ctx context.Context, acc telegraf.Accumulator, d *Database) {
+	if len(d.u.host) == 0 {
+		return
+	}
+	port := d.u.port
+	if len(port) == 0 {
+		port = "1521"
+	}
+
+	var fields = map[string]interface{}{"up": 0}
+	start := time.Now()
+	dialer := net.Dialer{Timeout: listenerTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(d.u.host, port))
+	if err != nil {
+		log.Printf("W! ora listener host=%s port=%s unreachable , %s", d.u.host, port, err)
+	} else {
+		conn.Close()
+		fields["up"] = 1
+		fields["connect_ms"] = float64(time.Since(start)) / float64(time.Millisecond)
+	}
+
+	acc.AddFields(o.measurementName("ora_listener"), fields, o.urlTags(d))
+}
@@ -0,0 +1,57 @@
+package ora
+
+import (
+	"strings"
+)
+
+//URL标签的默认名称
+var defaultUrlTags = map[string]string{
+	"host":     "orahost",
+	"port":     "oraport",
+	"service":  "oraservice",
+	"instance": "orainstance",
+}
+
+//插件输出的度量值名称：measurement_name替换默认度量值ora及内置度量值的ora_前缀
+//SQL自行指定的measurement不受影响
+func (o *Ora) measurementName(name string) string {
+	if len(o.MeasurementName) == 0 {
+		return name
+	}
+	if name == "ora" {
+		return o.MeasurementName
+	}
+	if strings.HasPrefix(name, "ora_") {
+		return o.MeasurementName + name[3:]
+	}
+	return name
+}
+
+//URL生成的标签，url_tags中设置为空的标签不输出
+//identity_tags开启且已查询到数据库标识时使用标识标签
+func (o *Ora) urlTags(d *Database) map[string]string {
+	if o.IdentityTags && d.identity != nil {
+		return d.identity.tags()
+	}
+
+	var tags = make(map[string]string)
+	for k, v := range map[string]string{
+		"host":     d.u.host,
+		"port":     d.u.port,
+		"service":  d.u.service,
+		"instance": d.u.instance,
+	} {
+		if len(v) == 0 {
+			continue
+		}
+
+		name, ok := o.UrlTags[k]
+		if !ok {
+			name = defaultUrlTags[k]
+		}
+		if len(name) > 0 {
+			tags[name] = v
+		}
+	}
+	return tags
+}
@@ -0,0 +1,68 @@
+package ora
+
+import (
+	"errors"
+	"strings"
+)
+
+//NULL处理方式
+// - skip_field   不输出该列
+// - skip_row     不输出该行
+// - zero         输出0（标签列为"0"）
+// - placeholder  输出null_placeholder（字段列为字符串字段）
+//未配置时保持原有行为：空字符串作为标签"NULL"，其它NULL值不输出
+const (
+	nullSkipField   = "skip_field"
+	nullSkipRow     = "skip_row"
+	nullZero        = "zero"
+	nullPlaceholder = "placeholder"
+)
+
+//null_policy=skip_row时parseRow返回，该行不输出
+var errSkipRow = errors.New("skip row")
+
+//NULL处理方式是否支持
+func validNullPolicy(p string) bool {
+	switch p {
+	case "", nullSkipField, nullSkipRow, nullZero, nullPlaceholder:
+		return true
+	}
+	return false
+}
+
+//列值是否为NULL，Oracle中空字符串即NULL
+func isNull(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return len(val) == 0
+	case []byte:
+		return len(val) == 0
+	}
+	return false
+}
+
+//列的NULL处理方式：null_policies > SQL的null_policy > 插件级null_policy
+func (o *Ora) nullPolicy(q *Query, col string) string {
+	for k, p := range q.NullPolicies {
+		if strings.EqualFold(k, col) {
+			return p
+		}
+	}
+	if len(q.NullPolicy) > 0 {
+		return q.NullPolicy
+	}
+	return o.NullPolicy
+}
+
+//null_policy=placeholder时输出的值，默认NULL
+func (o *Ora) nullPlaceholder(q *Query) string {
+	if len(q.NullPlaceholder) > 0 {
+		return q.NullPlaceholder
+	}
+	if len(o.NullPlaceholder) > 0 {
+		return o.NullPlaceholder
+	}
+	return "NULL"
+}
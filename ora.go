@@ -2,42 +2,365 @@ package ora
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/errchan"
 	"github.com/influxdata/telegraf/plugins/inputs"
-	ora "gopkg.in/rana/ora.v4"
+
+	"github.com/godror/godror"
+	go_ora "github.com/sijms/go-ora/v2"
 )
 
 //ora插件结构
 type Ora struct {
-	Url        string   `toml:"url"`
-	Files      []string `toml:"files"`      //SQL文件
-	SqlSeconds int64    `toml:"sqlseconds"` //单条SQL执行时间阀值
+	Url             string            `toml:"url"`
+	Urls            []string          `toml:"urls"`             //多个数据库URL
+	Databases       []*Database       `toml:"database"`         //[[inputs.ora.database]]配置块
+	Queries         []*Query          `toml:"query"`            //[[inputs.ora.query]]配置块
+	Driver          string            `toml:"driver"`           //数据库驱动
+	Files           []string          `toml:"files"`            //SQL文件
+	MeasurementName string            `toml:"measurement_name"` //替换默认度量值名称ora
+	UrlTags         map[string]string `toml:"url_tags"`         //URL标签名称
+	IdentityTags    bool              `toml:"identity_tags"`    //用v$database/v$instance的标识标签替代URL标签
+	CheckPrivileges bool              `toml:"check_privileges"` //首次连接后检查SQL引用对象的查询权限
+	DryRun          bool              `toml:"dry_run"`          //只解析SQL并报告列的映射，不执行
+	ReloadFiles     bool              `toml:"reload_files"`     //每次采集检查SQL文件变化
+	ReadOnly        bool              `toml:"read_only"`        //只允许SELECT及read_only_calls中的PL/SQL调用
+	ReadOnlyCalls   []string          `toml:"read_only_calls"`  //read_only时允许调用的PL/SQL过程
+	SqlSeconds      int64             `toml:"sqlseconds"`       //单条SQL执行时间阀值
+	TimeFormat      string            `toml:"time_format"`      //DATE/TIMESTAMP列的输出格式
+	MaxLobLength    int               `toml:"max_lob_length"`   //CLOB/LONG列最大字节数
+	NumberFormat    string            `toml:"number_format"`    //NUMBER列输出：float(默认)或auto
+	TrueValues      []string          `toml:"true_values"`      //field_types为bool/int时视为true的文本
+	NullPolicy      string            `toml:"null_policy"`      //NULL处理方式
+	NullPlaceholder string            `toml:"null_placeholder"` //null_policy=placeholder时输出的值
+	FalseValues     []string          `toml:"false_values"`     //field_types为bool/int时视为false的文本
+	FetchArraySize  int               `toml:"fetch_array_size"` //每次从数据库获取的行数
+	PrefetchRows    int               `toml:"prefetch_rows"`    //执行时预取的行数
+	Binds           map[string]string `toml:"binds"`            //SQL绑定变量
+	StateFile       string            `toml:"state_file"`       //增量采集水位持久化文件
+
+	MaxParallelQueries int               `toml:"max_parallel_queries"` //每个数据库同时执行的SQL数，0表示不限制
+	Serialize          bool              `toml:"serialize"`            //每个数据库只使用一个会话逐个执行SQL
+	SkipOverlapping    bool              `toml:"skip_overlapping"`     //上次采集未结束时跳过本次
+	PrepareStatements  bool              `toml:"prepare_statements"`   //缓存预编译语句
+	GatherTimeout      internal.Duration `toml:"gather_timeout"`       //整个采集周期的最长时间
+	QueryErrorMetric   bool              `toml:"query_error_metric"`   //SQL执行失败时输出ora_query_error度量
+	BreakerThreshold   int               `toml:"breaker_threshold"`    //连续失败次数阀值，0表示不熔断
+	BreakerBackoff     internal.Duration `toml:"breaker_backoff"`      //熔断时长
+	ConnectRetries     int               `toml:"connect_retries"`      //连接失败时的重试次数
+	ConnectBackoff     internal.Duration `toml:"connect_backoff"`      //首次重试间隔，之后每次加倍
+	RetryErrors        []string          `toml:"retry_errors"`         //SQL可重试的错误号
+	KillOnTimeout      bool              `toml:"kill_on_timeout"`      //SQL超时后终止执行该SQL的会话
+	SessionParams      map[string]string `toml:"session_params"`       //新会话建立后ALTER SESSION设置的参数
+	ApplicationInfo    bool              `toml:"application_info"`     //设置会话的module、action、client_identifier
+
+	//远程SQL文件
+	FilesHeaders            map[string]string `toml:"files_headers"`
+	FilesTimeout            internal.Duration `toml:"files_timeout"`
+	FilesCacheTTL           internal.Duration `toml:"files_cache_ttl"`
+	FilesSSLCA              string            `toml:"files_ssl_ca"`
+	FilesSSLCert            string            `toml:"files_ssl_cert"`
+	FilesSSLKey             string            `toml:"files_ssl_key"`
+	FilesInsecureSkipVerify bool              `toml:"files_insecure_skip_verify"`
+
+	//认证
+	WalletLocation string `toml:"wallet_location"` //Oracle Wallet目录
+	ConnectRole    string `toml:"connect_role"`    //sysdba、sysoper或sysasm
+	Username       string `toml:"username"`        //优先于URL中的用户名
+	Password       string `toml:"password"`        //优先于URL中的密码
+
+	//Vault数据库密钥引擎
+	VaultAddress            string `toml:"vault_address"`
+	VaultToken              string `toml:"vault_token"`
+	VaultNamespace          string `toml:"vault_namespace"`
+	VaultPath               string `toml:"vault_path"` //如database/creds/ora-monitor
+	VaultSSLCA              string `toml:"vault_ssl_ca"`
+	VaultInsecureSkipVerify bool   `toml:"vault_insecure_skip_verify"`
+
+	//TLS
+	Protocol           string `toml:"protocol"` //tcp(默认)或tcps
+	SSLCA              string `toml:"ssl_ca"`
+	SSLCert            string `toml:"ssl_cert"`
+	SSLKey             string `toml:"ssl_key"`
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
+
+	//内置采集项
+	UseDefaultQueries     bool `toml:"use_default_queries"`
+	GatherTablespaces     bool `toml:"gather_tablespaces"`
+	GatherWaitEvents      bool `toml:"gather_wait_events"`
+	GatherSessions        bool `toml:"gather_sessions"`
+	GatherBlocking        bool `toml:"gather_blocking"`
+	GatherTransactions    bool `toml:"gather_transactions"`
+	GatherTemp            bool `toml:"gather_temp"`
+	GatherUndo            bool `toml:"gather_undo"`
+	GatherFileIo          bool `toml:"gather_file_io"`
+	GatherServices        bool `toml:"gather_services"`
+	GatherResourceManager bool `toml:"gather_resource_manager"`
+	GatherCache           bool `toml:"gather_cache"`
+	GatherOsstat          bool `toml:"gather_osstat"`
+	GatherAsh             bool `toml:"gather_ash"`
+	DiagnosticsPack       bool `toml:"diagnostics_pack"`
+	GatherAwr             bool `toml:"gather_awr"`
+	GatherExadata         bool `toml:"gather_exadata"`
+	GatherInterconnect    bool `toml:"gather_interconnect"`
+	GatherGoldengate      bool `toml:"gather_goldengate"`
+	GatherListener        bool `toml:"gather_listener"`
+	GatherInvalidObjects  bool `toml:"gather_invalid_objects"`
+	GatherSegments        bool `toml:"gather_segments"`
+	GatherRedo            bool `toml:"gather_redo"`
+	GatherFlashback       bool `toml:"gather_flashback"`
+	GatherFeatureUsage    bool `toml:"gather_feature_usage"`
+	GatherScheduler       bool `toml:"gather_scheduler"`
+	GatherAlertLog        bool `toml:"gather_alert_log"`
+	GatherMemory          bool `toml:"gather_memory"`
+	GatherSysmetric       bool `toml:"gather_sysmetric"`
+	GatherDataguard       bool `toml:"gather_dataguard"`
+	GatherBackups         bool `toml:"gather_backups"`
+	GatherRecovery        bool `toml:"gather_recovery"`
+	GatherAsm             bool `toml:"gather_asm"`
+	GatherPdbs            bool `toml:"gather_pdbs"`
+	RacMode               bool `toml:"rac_mode"`
+
+	PdbInclude []string `toml:"pdb_include"`
+	PdbExclude []string `toml:"pdb_exclude"`
+
+	SysmetricInclude []string `toml:"sysmetric_include"`
+	SysmetricExclude []string `toml:"sysmetric_exclude"`
+
+	GatherSysstat []string `toml:"gather_sysstat"`
+	GatherTopSql  int      `toml:"gather_top_sql"`
+	GatherAudit   string   `toml:"gather_audit"`
+	GatherUsers   []string `toml:"gather_users"`
+
+	StatspackSchema  string            `toml:"statspack_schema"`
+	GoldengateSchema string            `toml:"goldengate_schema"`
+	SegmentsInterval internal.Duration `toml:"segments_interval"`
+	SegmentsTop      int               `toml:"segments_top"`
+
+	//连接池
+	MaxOpenConnections    int               `toml:"max_open_connections"`
+	MaxIdleConnections    int               `toml:"max_idle_connections"`
+	MaxConnectionLifetime internal.Duration `toml:"max_connection_lifetime"`
 
 	sync.Mutex
-	sqlmap map[string][]string
-	u      *url //解析后的数据库URL
+	queries  []*Query             //配置块与SQL文件合并后的SQL列表
+	lastRun  map[string]time.Time //设置了interval的SQL上次执行时间
+	dbs      []*Database          //url、urls及database配置块合并后的数据库列表
+	tls      *tls.Config          //go-ora使用的TLS配置
+	hostname string               //绑定变量hostname
+	files    map[string]*sqlFile  //已加载的SQL文件，文件变化时重新解析
+	defaults []*Query             //内置默认query pack
+	client   *http.Client         //获取远程SQL文件
+
+	vaultMu     sync.Mutex
+	vaultClient *http.Client //访问Vault
+
+	cancelMu sync.Mutex
+	cancel   context.CancelFunc //取消进行中的采集
+
+	skipped     int64 //skip_overlapping跳过的采集次数
+	initialized bool  //Init已成功执行
+
+	sessionStmts []string //session_params生成的ALTER SESSION语句
+
+	stateMu    sync.Mutex
+	watermarks map[string]time.Time //增量采集水位，即绑定变量last_run_time
+
+	sysmetricInclude filter.Filter
+	sysmetricExclude filter.Filter
+	pdbInclude       filter.Filter
+	pdbExclude       filter.Filter
+	users            filter.Filter
+	readOnlyCalls    filter.Filter
+}
+
+//数据库配置
+type Database struct {
+	Url            string `toml:"url"`
+	WalletLocation string `toml:"wallet_location"` //为空时使用插件级wallet_location
+	ConnectRole    string `toml:"connect_role"`    //为空时使用插件级connect_role或URL中的as子句
+	Username       string `toml:"username"`        //为空时使用插件级username或URL中的用户名
+	Password       string `toml:"password"`        //为空时使用插件级password或URL中的密码
+	VaultPath      string `toml:"vault_path"`      //为空时使用插件级vault_path
+
+	Tags map[string]string `toml:"tags"` //该数据库所有度量值的静态标签
+
+	u  *url    //解析后的数据库URL
+	db *sql.DB //跨采集周期保持的连接池
+
+	lease *vaultLease //Vault动态凭据租约
+
+	mu       sync.Mutex
+	last     map[string]counter   //差分计算用的上次采集值
+	breakers map[string]*breaker  //连续失败的SQL
+	stmts    map[string]*sql.Stmt //prepare_statements缓存的预编译语句
+
+	instances  map[string]string //rac_mode下inst_id对应的实例名
+	version    string            //v$instance.version，连接后查询一次，为空时未查询
+	role       string            //v$database.database_role，连接后查询一次，为空时未查询
+	identity   *identity         //identity_tags开启时的数据库标识
+	reidentify bool              //连接已重建，需要重新查询标识
+	privileged bool              //check_privileges已检查
+}
+
+//SQL配置
+type Query struct {
+	Name        string            `toml:"name"`
+	Sql         string            `toml:"sql"`
+	Timeout     internal.Duration `toml:"timeout"`     //为0时使用sqlseconds
+	Measurement string            `toml:"measurement"` //为空时使用ora
+	Interval    internal.Duration `toml:"interval"`    //执行间隔，为0时每次采集都执行
+
+	//显式指定列作为标签或字段，未指定的列按类型决定：字符串为标签，数值为字段
+	TagColumns    []string `toml:"tag_columns"`
+	FieldColumns  []string `toml:"field_columns"`
+	IgnoreColumns []string `toml:"ignore_columns"`
+
+	FieldTypes      map[string]string `toml:"field_types"`      //列=>int、float、bool、string，指定的列均作为字段
+	TimeFormat      string            `toml:"time_format"`      //为空时使用插件级time_format
+	MaxLobLength    int               `toml:"max_lob_length"`   //为0时使用插件级max_lob_length
+	Binds           map[string]string `toml:"binds"`            //绑定变量，优先于插件级binds
+	Cursor          string            `toml:"cursor"`           //PL/SQL返回REF CURSOR的绑定变量名，默认cur
+	FetchArraySize  int               `toml:"fetch_array_size"` //为0时使用插件级fetch_array_size
+	PrefetchRows    int               `toml:"prefetch_rows"`    //为0时使用插件级prefetch_rows
+	MaxRows         int               `toml:"max_rows"`         //最多读取的行数，0表示不限制
+	Tags            map[string]string `toml:"tags"`             //本条SQL度量值的静态标签
+	ValueMap        map[string]string `toml:"value_map"`        //列=>"文本:值|文本:值"，映射后作为字段
+	NullPolicy      string            `toml:"null_policy"`      //为空时使用插件级null_policy
+	NullPlaceholder string            `toml:"null_placeholder"` //为空时使用插件级null_placeholder
+	NullPolicies    map[string]string `toml:"null_policies"`    //列=>NULL处理方式
+	PivotKey        string            `toml:"pivot_key"`        //行转列：作为字段名的列
+	PivotValue      string            `toml:"pivot_value"`      //行转列：作为字段值的列
+	Aggregate       string            `toml:"aggregate"`        //多行合并为一个度量值：sum、last
+	AggregateTags   []string          `toml:"aggregate_tags"`   //聚合时保留的标签列
+	Transforms      map[string]string `toml:"transforms"`       //累计列=>delta、rate，输出增量或每秒增量
+	Top             int               `toml:"top"`              //只输出top_by字段值最大的前N行
+	TopBy           []string          `toml:"top_by"`           //top排序字段，多个时分别取前N行后合并
+	TimeColumn      string            `toml:"time_column"`      //作为度量值时间的DATE/TIMESTAMP列，为空时使用采集时间
+	Retries         int               `toml:"retries"`          //出现retry_errors中的错误时的重试次数
+	MinVersion      string            `toml:"min_version"`      //适用的最低数据库版本，如12.1或12c
+	MaxVersion      string            `toml:"max_version"`      //适用的最高数据库版本，只比较给出的位数
+	Role            string            `toml:"role"`             //适用的数据库角色，如PRIMARY、PHYSICAL STANDBY，|分隔多个
+
+	deltas    []string                          //输出差分值的累计列
+	valueMaps map[string]map[string]string      //解析后的value_map
+	filter    func(tags map[string]string) bool //返回false的行不输出
+}
+
+//列的字段类型，未指定时返回空
+func (q *Query) fieldType(col string) string {
+	for k, t := range q.FieldTypes {
+		if strings.EqualFold(k, col) {
+			return t
+		}
+	}
+	return ""
+}
+
+//调度用的SQL标识
+func (q *Query) key() string {
+	return q.Name + "\x00" + q.Sql
+}
+
+//检查配置块或query pack中的SQL
+func (q *Query) validate() error {
+	if len(q.Name) == 0 || len(q.Sql) == 0 {
+		return fmt.Errorf("must have both name and sql")
+	}
+	if !validTimeFormat(q.TimeFormat) {
+		return fmt.Errorf("time_format=%s not support", q.TimeFormat)
+	}
+	for k, t := range q.FieldTypes {
+		if !validFieldType(t) {
+			return fmt.Errorf("field_types %s=%s not support", k, t)
+		}
+	}
+	if !validNullPolicy(q.NullPolicy) {
+		return fmt.Errorf("null_policy=%s not support", q.NullPolicy)
+	}
+	for k, p := range q.NullPolicies {
+		if !validNullPolicy(p) {
+			return fmt.Errorf("null_policies %s=%s not support", k, p)
+		}
+	}
+	for k, t := range q.Transforms {
+		if !validTransform(t) {
+			return fmt.Errorf("transforms %s=%s not support", k, t)
+		}
+	}
+	if !validAggregate(q.Aggregate) {
+		return fmt.Errorf("aggregate=%s not support", q.Aggregate)
+	}
+
+	vms, err := parseValueMap(q.ValueMap)
+	if err != nil {
+		return err
+	}
+	q.valueMaps = vms
+
+	if _, ok := parseVersion(q.MinVersion); len(q.MinVersion) > 0 && !ok {
+		return fmt.Errorf("min_version=%s format error", q.MinVersion)
+	}
+	if _, ok := parseVersion(q.MaxVersion); len(q.MaxVersion) > 0 && !ok {
+		return fmt.Errorf("max_version=%s format error", q.MaxVersion)
+	}
+	return nil
 }
 
 //数据库连接串结构
 type url struct {
-	all      string
-	user     string
-	passwd   string
-	host     string
-	port     string
-	service  string
-	instance string
+	all        string
+	user       string
+	passwd     string
+	connect    string //@之后的连接串
+	role       string //as子句指定的特权角色
+	descriptor string //TNS别名解析后或直接配置的连接描述符
+	host       string
+	port       string
+	service    string
+	instance   string
+}
+
+//隐藏密码后的URL，日志和错误信息中只能使用该形式
+func (u *url) redacted() string {
+	return redactUrl(u.all)
+}
+
+//隐藏错误信息中可能由驱动带出的密码
+func (u *url) mask(err error) string {
+	if len(u.passwd) == 0 {
+		return err.Error()
+	}
+	return strings.Replace(err.Error(), u.passwd, "****", -1)
+}
+
+//隐藏URL中的密码：最后一个@之前、第一个/之后的部分
+func redactUrl(raw string) string {
+	i := strings.LastIndex(raw, "@")
+	if i < 0 {
+		return raw
+	}
+	j := strings.Index(raw[:i], "/")
+	if j < 0 || j == i-1 {
+		return raw
+	}
+	return raw[:j+1] + "****" + raw[i:]
 }
 
 var sampleConfig = `
@@ -48,18 +371,467 @@ var sampleConfig = `
   ## 示例：
   ##   [user][/password][@]host:port/oracle_service_name[:pooled]
   ##   [user][/password][@]host:port/oracle_service_name[:pooled] as sysdba 
+  ##   user/password@TNS_ALIAS      （从wallet目录、$TNS_ADMIN或$ORACLE_HOME/network/admin的tnsnames.ora解析标签）
+  ##   user/password@(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=host)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=orcl)))
   url = "perfstat/perfstat@localhost:1521/orcl"
+  ## 同时采集多个数据库，每个数据库的度量值带有各自的URL标签
+  # urls = ["perfstat/perfstat@db1:1521/orcl/orcl1", "perfstat/perfstat@db2:1521/orcl/orcl2"]
+  ## 也可使用[[inputs.ora.database]]配置块逐个指定数据库，见本示例末尾
+
+  ## Oracle Wallet（外部口令存储）目录，配置后url可省略用户名和密码：
+  ##   /@host:port/oracle_service_name/instance_name
+  ##   host:port/oracle_service_name/instance_name
+  ## 也可在[[inputs.ora.database]]中为单个数据库指定
+  # wallet_location = "/etc/telegraf/wallet"
+
+  ## 用户名与密码，配置后优先于url中的用户名和密码，url可只写连接串，如 db1:1521/orcl
+  ## 支持以下引用方式，避免在配置文件中保存明文密码：
+  ##   env:NAME     读取环境变量NAME
+  ##   file:PATH    读取文件内容（去除首尾空白），如Docker/Kubernetes secret
+  ## 也可使用telegraf配置文件的环境变量替换，如 password = "$ORA_PASSWORD"
+  ## 也可在[[inputs.ora.database]]中为单个数据库指定
+  # username = "perfstat"
+  # password = "env:ORA_PASSWORD"
+
+  ## 从HashiCorp Vault数据库密钥引擎获取动态凭据，配置后忽略username/password
+  ## 租约剩余不足1/3时续租，无法续租或达到最大期限时重新申请并重建连接，停止时撤销租约
+  ## vault_address、vault_token为空时使用环境变量VAULT_ADDR、VAULT_TOKEN，vault_token支持env:、file:引用
+  ## 也可在[[inputs.ora.database]]中为单个数据库指定vault_path
+  # vault_address = "https://vault.example.com:8200"
+  # vault_token = "file:/etc/telegraf/vault_token"
+  # vault_namespace = ""
+  # vault_path = "database/creds/ora-monitor"
+  # vault_ssl_ca = "/etc/telegraf/vault_ca.pem"
+  # vault_insecure_skip_verify = false
+
+  ## 特权连接角色：sysdba、sysoper、sysasm，用于访问mount/standby状态数据库的受限视图
+  ## URL末尾的 as sysdba 子句同样有效
+  # connect_role = "sysdba"
+
+  ## 连接协议，tcps表示使用TLS加密连接（如Autonomous Database）
+  # protocol = "tcps"
+  ## TLS证书（仅go-ora驱动支持），godror驱动的证书需放在wallet_location中
+  # ssl_ca = "/etc/telegraf/ca.pem"
+  # ssl_cert = "/etc/telegraf/cert.pem"
+  # ssl_key = "/etc/telegraf/key.pem"
+  ## 不校验服务端证书
+  # insecure_skip_verify = false
+
+  ## 数据库驱动：
+  ##    godror  需运行环境安装Oracle Instant Client（默认）
+  ##    go-ora  纯Go实现，无需Oracle客户端，适用于容器部署
+  # driver = "godror"
   ## 指定需要采集生成度量值的SQL语句文件
   ## 文件内容的格式要求  SQL-name::SQL-Statement;;
   ## SQL-name是#号开头表示忽略此条SQL。 
+  ## SQL-name后可用方括号指定属性，如 tablespace[measurement=ora_tablespace]::SELECT ...;;
+  ##   measurement  度量值名称，默认ora
+  ##   interval     执行间隔，如10m，用于开销较大的SQL，默认每次采集都执行
+  ##   timeout      本条SQL执行的最大秒数（如60）或时长（如2m），默认sqlseconds
+  ##   tag_columns、field_columns、ignore_columns  作为标签/字段/忽略的列，多列以|分隔
+  ##   field_types  字段类型，如 blocks:int|ratio:float|status:bool
+  ##   transforms   累计列转换，delta输出本周期增量，rate输出每秒增量，如 total_waits:delta|executions:rate，
+  ##                首次采集或计数器变小（实例重启）时不输出该字段
+  ##   time_format  本条SQL的DATE/TIMESTAMP输出格式，见time_format
+  ##   max_lob_length  本条SQL的CLOB/LONG列最大字节数
+  ##   binds        绑定变量，如 owner:APP|since:2020-01-01
+  ##   tags         静态标签，如 team:dba|tier:gold
+  ##   null_policy、null_placeholder  本条SQL的NULL处理方式，见null_policy
+  ##   pivot_key、pivot_value  行转列：pivot_key列的值作为字段名（小写，非字母数字替换为_），
+  ##                pivot_value列的值作为字段值，其余标签相同的行合并为一个度量值，
+  ##                如 sysstat[pivot_key=name,pivot_value=value]::SELECT name, value FROM v$sysstat;;
+  ##   aggregate    所有行合并为一个度量值：sum数值字段求和、last取最后一行，默认每行一个度量值
+  ##   aggregate_tags  聚合时保留的标签列，多个用|分隔，标签相同的行合并，
+  ##                如每个RAC节点或每个PDB一行的结果合并为一个度量值
+  ##   top、top_by  只输出top_by字段值（transforms差分后）最大的前top行，top_by多个字段用|分隔，
+  ##                分别取前top行后合并，如 top=10,top_by=elapsed_time|buffer_gets
+  ##   time_column  作为度量值时间的DATE/TIMESTAMP列，该列不再作为标签或字段输出，默认使用采集时间
+  ##   retries      出现retry_errors中的错误且未读取到任何行时的重试次数，默认不重试
+  ##   cursor       PL/SQL条目返回SYS_REFCURSOR的绑定变量名，默认cur
+  ##   min_version  适用的最低数据库版本，如12.1、12c
+  ##   max_version  适用的最高数据库版本，只比较给出的位数，如11.2包含11.2.0.4
+  ##   max_rows     最多读取的行数，超过时停止读取并在ora_internal中记录truncated=1，默认不限制
+  ##   fetch_array_size  本条SQL每次获取的行数
+  ##   prefetch_rows     本条SQL执行时预取的行数
+  ##   role         适用的数据库角色（v$database.database_role），如PRIMARY、PHYSICAL STANDBY，|分隔多个
+  ## 设置了min_version/max_version的SQL只在版本符合（v$instance.version）的数据库上执行
+  ## 设置了role的SQL只在角色符合的数据库上执行，避免在只读或MOUNT状态的备库上报错
+  ## 以BEGIN或DECLARE开头的条目作为PL/SQL调用，读取其REF CURSOR结果集，如：
+  ##   metrics::BEGIN pkg.get_metrics(:cur); END;;
+  ## 条目以;;结尾时插件会补全END后的分号
+  ## 支持通配符（如 /etc/telegraf/ora.d/*.sql）和目录（递归加载其中的*.sql文件，按路径排序）
+  ## 文件中可使用 -- 行注释、/* */ 块注释（/*+ */ 提示保留）以及条目之间以#开头的整行注释
+  ## 扩展名为.toml的文件按query pack格式解析，每个[[query]]表的字段与[[inputs.ora.query]]相同，
+  ## 另支持schedule（interval的别名）
+  ## 文件开头（第一条SQL之前）可用@开头的行为文件中所有SQL指定默认值，SQL自身的属性优先，如：
+  ##   @measurement ora_custom
+  ##   @tags team=dba,env=prod
+  ## query pack在第一个[[query]]之前用measurement、tags（如 tags = {team = "dba"}）指定
+  ## 以http://或https://开头的为远程文件
   files = ["default.sql"]
-  ## SQL-file中每条SQL执行的最大秒数
+  ## 文件在插件启动时解析，开启后每次采集检查文件变化：本地文件按修改时间和大小判断，
+  ## 远程文件在files_cache_ttl内使用缓存，之后按ETag/Last-Modified检查更新
+  ## 重新加载失败时继续使用上次的内容
+  # reload_files = false
+
+  ## 只读检查（默认开启）：SQL文件、query pack及配置块中的SQL只允许SELECT/WITH查询（不允许FOR UPDATE），
+  ## 以及对read_only_calls中过程（支持通配符，不区分大小写，过程名不能加双引号）的单个调用，参数只能是绑定变量或常量，
+  ## 如 BEGIN pkg.get_metrics(:cur); END; 其它语句拒绝执行，防止共享SQL文件中混入UPDATE等修改数据的语句
+  # read_only = true
+  # read_only_calls = ["MONITOR.PKG_METRICS.*"]
+
+  ## 权限自检（默认关闭）：每个数据库首次连接成功后，逐个检查适用的SQL引用的v$、gv$、DBA_、CDB_视图
+  ## 及FROM/JOIN中带schema的对象能否查询，缺少权限的对象输出错误日志（含引用的SQL及需要执行的GRANT语句）
+  ## 及ora_privileges度量（object标签，missing、queries字段）
+  # check_privileges = false
+
+  ## 试运行：不执行SQL，对适用于各数据库的全部SQL（含内置采集项及SQL文件）在外层包一层WHERE 1 = 0
+  ## 只解析得到结果集的列，每条SQL输出一行ora_dry_run度量（query标签，ok、error字段，
+  ## 以及tags、fields、ignores、pivot_keys、pivot_values、times字段列出各去向的列）及日志，
+  ## 配合telegraf --test在上线新SQL文件或query pack前检查SQL能否解析及列作为标签还是字段，PL/SQL调用不解析
+  # dry_run = false
+  ## 远程文件的请求头、超时、缓存时间与TLS配置
+  # files_headers = {Authorization = "Bearer xxx"}
+  # files_timeout = "10s"
+  # files_cache_ttl = "5m"
+  # files_ssl_ca = "/etc/telegraf/ca.pem"
+  # files_ssl_cert = "/etc/telegraf/cert.pem"
+  # files_ssl_key = "/etc/telegraf/key.pem"
+  # files_insecure_skip_verify = false
+  ## SQL-file中每条SQL执行的最大秒数，默认10
   sqlseconds = 10
+  ## 每个数据库同时执行的SQL数（每条SQL占用一个会话），0表示所有SQL同时执行
+  # max_parallel_queries = 0
+  ## 每个数据库只使用一个会话，所有SQL（含多租户容器切换）逐个执行，
+  ## 适用于对会话数敏感的数据库，开启后忽略max_parallel_queries和连接池设置
+  ## 此时SQL总耗时应小于采集间隔
+  # serialize = false
+  ## 上次采集未结束时跳过本次采集，而不是排队等待，跳过次数累计输出到ora_internal的gathers_skipped字段
+  # skip_overlapping = false
+  ## 整个采集周期（含监听探测、连接及重试、标识与权限检查）的最长时间，超过后取消所有未完成的连接和SQL，0表示不限制
+  # gather_timeout = "50s"
+  ## 每条SQL在每个连接上只预编译一次，之后的采集复用，减少数据库硬解析
+  ## 每条SQL在每个会话上保持一个打开的游标，SQL较多时注意数据库open_cursors参数
+  ## SQL文件变化或重新连接时关闭失效的语句
+  # prepare_statements = false
+  ## DATE/TIMESTAMP列的输出方式：
+  ##   不设置    作为"2006-01-02 15:04:05"格式的标签
+  ##   rfc3339   RFC3339格式字符串字段
+  ##   epoch     Unix秒字段
+  ##   epoch_ms  Unix毫秒字段
+  ## INTERVAL DAY TO SECOND列总是输出为秒数字段
+  # time_format = "epoch"
+  ## CLOB/NCLOB/LONG列作为字符串字段输出，超过此字节数时截断，默认4096，负数表示不限制
+  # max_lob_length = 4096
+  ## NUMBER列的输出方式（godror驱动）：
+  ##   float  转为float64，超过2^53的整数（大ID、字节数）会丢失精度
+  ##   auto   整数且在int64范围内时输出int64，其余为float64
+  ## 已写入InfluxDB的float字段改为auto后会产生字段类型冲突，可用field_types将个别列固定为float或string
+  # number_format = "float"
+  ## field_types为bool或int的字符串列中视为true/false（1/0）的文本，不区分大小写
+  ## 都不设置时默认为 YES/Y/TRUE/ON/OPEN/ONLINE/ENABLED/VALID 与 NO/N/FALSE/OFF/CLOSED/OFFLINE/DISABLED/INVALID
+  # true_values = ["YES", "OPEN"]
+  # false_values = ["NO", "CLOSED"]
+  ## NULL（含空字符串）的处理方式：
+  ##   不设置       空字符串作为标签"NULL"，其它NULL值不输出
+  ##   skip_field   不输出该列
+  ##   skip_row     不输出该行
+  ##   zero         输出0，标签列为"0"
+  ##   placeholder  输出null_placeholder（默认NULL）
+  ## 可在每条SQL上设置null_policy，或用null_policies按列设置
+  # null_policy = "skip_field"
+  # null_placeholder = "NULL"
+  ## 大结果集（段列表、会话列表等）的获取批量，减少网络往返，0表示使用驱动默认值
+  ## fetch_array_size为每次获取的行数，prefetch_rows为执行时随结果一同返回的行数
+  ## godror驱动可在每条SQL上单独设置；go-ora驱动只支持插件级prefetch_rows
+  # fetch_array_size = 0
+  # prefetch_rows = 0
+
+  ## 内置采集项，无需编写SQL
+  ## 每次采集总会输出ora_up：up（1/0）、connect_ms、open_mode、database_status，
+  ## 其他SQL均失败时也输出，用于区分数据库故障与采集端故障
+  ## 内置默认query pack（default_queries.toml）：表空间使用率（ora_tablespace_usage）、
+  ## 会话数（ora_session_count）、等待类别（ora_wait_class）及常用v$sysstat累计值（ora_sysstat_value，每个统计项一行）
+  ## 未配置任何SQL文件时也能输出基本监控指标
+  # use_default_queries = false
+  ## 表空间使用量（ora_tablespace）：已分配/已用/剩余/自动扩展上限字节数及使用率，含临时表空间
+  # gather_tablespaces = false
+  ## 等待事件（ora_wait_event）：按等待类别输出本周期等待次数与等待微秒数的增量
+  # gather_wait_events = false
+  ## 会话（ora_sessions）：按状态、类型、等待类别、用户名、客户端机器统计会话数及被阻塞会话数
+  # gather_sessions = false
+  ## 阻塞（ora_blocking）：按阻塞链最终阻塞会话（blocking_sid、blocking_serial标签）输出被阻塞会话数与最长等待秒数，
+  ## 另输出全库被阻塞会话总数及按锁类型（v$lock）的等待锁数，阻塞链需要11.2及以上版本
+  # gather_blocking = false
+  ## Top SQL（ora_top_sql）：v$sqlstats中最近一小时执行过的SQL，按本周期耗时、CPU时间、逻辑读分别取前N条，
+  ## sql_id及截断的sql_text为标签，执行次数、耗时等输出本周期增量，首次采集不输出，0表示关闭
+  # gather_top_sql = 0
+  ## 事务（ora_transactions）：按用户名与module输出未提交事务数、最长事务秒数、已用UNDO块数及正在回滚的事务数
+  # gather_transactions = false
+  ## 临时表空间（ora_temp）：v$tempseg_usage按表空间、用户、段类型（SORT、HASH等）输出会话数与使用字节数
+  # gather_temp = false
+  ## UNDO（ora_undo）：v$undostat当前10分钟统计周期的UNDO块数、事务数、最长查询秒数、
+  ## ORA-01555次数（snapshot_too_old_errors）、空间不足次数及活动/未过期/已过期块数
+  # gather_undo = false
+  ## 文件I/O（ora_file_io）：v$filestat/v$tempstat按表空间、文件名输出读写次数、字节数及读写毫秒数的本周期增量，
+  ## 用于定位热点数据文件，需开启timed_statistics才有耗时
+  # gather_file_io = false
+  ## 服务（ora_service）：按service_name输出v$servicemetric最近一分钟的每次调用耗时、CPU、每秒调用数，
+  ## 及v$service_stats中DB time、DB CPU、user calls等累计值的本周期增量（db_time、db_cpu等字段）
+  # gather_services = false
+  ## Resource Manager（ora_resource_manager）：按消费者组输出活动会话数、等待执行及排队会话数，
+  ## CPU等待次数/毫秒数、消耗CPU毫秒数等输出本周期增量，用于发现被Resource Manager限流的负载
+  # gather_resource_manager = false
+  ## 缓存（ora_cache）：v$librarycache按namespace输出gets、pins、reloads、invalidations的本周期增量及累计命中率，
+  ## v$result_cache_statistics的统计项转为字段（如find_count、invalidation_count），累计次数输出本周期增量
+  # gather_cache = false
+  ## 操作系统（ora_osstat）：v$osstat中数据库主机的CPU数、负载、物理/空闲内存，及busy_time、idle_time等
+  ## CPU时间（厘秒）的本周期增量，采集端远程部署无法读取数据库主机/proc时使用
+  # gather_osstat = false
+  ## 活动会话（ora_ash）：按等待类别（CPU表示在CPU上）、sql_id、module输出活动会话数
+  # gather_ash = false
+  ## 已购买Diagnostics Pack许可时开启，ora_ash改为读取上次采集以来的v$active_session_history并输出平均活动会话数；
+  ## 默认关闭，只采样当前v$session，不访问需要许可的视图
+  # diagnostics_pack = false
+  ## AWR回填（ora_awr_sysmetric、ora_awr_sysstat）：读取上次成功采集以来结束的AWR快照，
+  ## 以快照结束时间作为度量值时间，dba_hist_sysmetric_summary按sysmetric_include/exclude过滤，
+  ## dba_hist_sysstat取gather_sysstat中的统计项（为空时取常用统计项）并输出相邻快照间的增量
+  ## 配合state_file可回填采集端停机期间的数据
+  ## 未开启diagnostics_pack（如标准版）时改为读取Statspack快照表，输出ora_awr_sysstat
+  ## 及按等待类别汇总的ora_awr_wait_class，需要已安装Statspack并定时执行快照
+  # gather_awr = false
+  ## Statspack所在的用户
+  # statspack_schema = "PERFSTAT"
+  ## Exadata（ora_exadata）：v$sysstat中智能扫描可卸载/节省字节数、存储索引节省字节数、闪存缓存命中次数等的
+  ## 本周期增量及v$cell中的存储单元数，只在Exadata上开启，其他平台上相关视图不存在
+  # gather_exadata = false
+  ## RAC互联（ora_interconnect）：各实例gc cr/current块接收数与接收时间（厘秒）、丢失块数等的本周期增量，
+  ## 及各实例对（inst_id、remote_inst_id）之间传输块数的本周期增量，直接使用gv$视图，只在RAC上开启
+  # gather_interconnect = false
+  ## 内存（ora_memory）：v$sga、v$sgastat（按池汇总）、v$pgastat及SGA/PGA目标建议
+  # gather_memory = false
+  ## 系统指标（ora_sysmetric）：v$sysmetric短周期指标，metric_name为标签，value为字段
+  # gather_sysmetric = false
+  ## 按metric_name过滤，支持通配符，用于控制序列数
+  # sysmetric_include = ["Host CPU*", "Executions Per Sec", "User Commits Per Sec"]
+  # sysmetric_exclude = []
+  ## 统计值（ora_sysstat）：v$sysstat中列出的统计项，每个实例一个度量值，
+  ## 统计名转为字段名（小写，非字母数字替换为_，如user_commits、parse_count__hard_），输出本周期增量
+  # gather_sysstat = ["user commits", "parse count (hard)", "execute count", "physical reads"]
+  ## GoldenGate（ora_goldengate）：按进程名（process_name标签）输出集成抽取进程的状态、是否运行及延迟秒数
+  # gather_goldengate = false
+  ## GoldenGate心跳表所在用户（ADD HEARTBEATTABLE创建的GG_LAG视图），设置后按复制路径输出replicat延迟秒数
+  # goldengate_schema = "GGADMIN"
+  ## 监听（ora_listener）：每次采集前TCP连接URL中的监听地址，输出是否可达（up）及连接毫秒数（connect_ms），
+  ## 用于区分监听故障与数据库故障，TNS别名或连接描述符形式的URL不探测
+  # gather_listener = false
+  ## Data Guard（ora_dataguard）：传输/应用延迟秒数、应用速率、归档目的地状态及日志缺口
+  ## 主库和备库均可开启
+  # gather_dataguard = false
+  ## RMAN备份（ora_backup）：按备份类型输出最近一次备份状态、距今秒数、大小及耗时，每5分钟执行
+  # gather_backups = false
+  ## 无效对象（ora_invalid_objects）：按属主和对象类型输出无效对象数、编译错误数（dba_errors）
+  ## 及近一小时DDL变更（重新编译）的对象数，另输出全库无效对象总数，每5分钟执行
+  # gather_invalid_objects = false
+  ## 调度作业（ora_scheduler）：按属主和作业名输出DBMS_SCHEDULER作业的运行/失败次数、上次运行秒数、
+  ## 是否超期未运行（overdue）及近一小时失败运行次数，DBMS_JOB作业输出broken状态
+  # gather_scheduler = false
+  ## 告警日志（ora_alert_log）：通过v$diag_alert_ext读取上次成功采集以来的ORA-错误，
+  ## 按错误号（error_code标签）输出次数，最近一条消息为last_message字段，无需访问数据库服务器文件系统
+  ## 需要12.2及以上版本，首次采集不回溯历史告警，state_file可在重启后继续
+  # gather_alert_log = false
+  ## 审计（ora_audit）：上次成功采集以来按用户名、客户端主机统计登录失败次数（failed_logons）、
+  ## 系统权限使用次数（privilege_uses，privilege标签）及被拒绝的操作次数（violations，policy标签）
+  ## unified读取unified_audit_trail（12c统一审计），traditional读取dba_audit_trail，为空时关闭
+  # gather_audit = ""
+  ## 数据库用户（ora_users）：列出的用户（支持通配符）的账户状态（account_status标签）、
+  ## 是否锁定/过期/处于宽限期及距密码过期天数，用于在监控或应用账户过期前告警，每5分钟执行
+  # gather_users = ["TELEGRAF", "APP_*"]
+  ## 段（ora_segments）：dba_segments按属主与段类型输出段数、字节数、块数，用于容量趋势，
+  ## segments_top大于0时另输出最大的N个段，查询较重，默认每小时执行一次
+  # gather_segments = false
+  # segments_interval = "1h"
+  # segments_top = 0
+  ## Redo（ora_redo）：redo size、redo writes、redo synch time等统计项，及log file sync、log file parallel write等
+  ## 等待事件（event标签）的等待次数与微秒数的本周期增量，按线程输出当前日志序列号及本周期日志切换次数
+  # gather_redo = false
+  ## 归档与快速恢复区（ora_recovery）：近一小时日志切换次数、归档日志量及FRA使用率
+  # gather_recovery = false
+  ## 闪回（ora_flashback）：是否开启闪回、闪回日志大小、最早可闪回时间距今秒数及在快速恢复区中的占比，
+  ## 各还原点（restore_point、guaranteed标签）的大小与存在秒数，及还原点总数、担保还原点数，
+  ## 用于发现遗留的担保还原点占满快速恢复区
+  # gather_flashback = false
+  ## 特性使用（ora_feature_usage）：dba_feature_usage_statistics中用过的特性（feature、license_option标签）
+  ## 是否正在使用、检测到的使用次数及最近使用距今秒数，另按Partitioning、Diagnostics Pack等常见付费选件汇总，
+  ## 用于发现意外使用的付费选件，选件归类仅供参考，每小时执行
+  # gather_feature_usage = false
+  ## ASM磁盘组（ora_asm）：总量/空闲/可用MB、冗余类型、磁盘数及离线磁盘数
+  ## 可在数据库实例上开启，也可直接连接ASM实例，如：
+  ##   url = "sys/password@host:1521/+ASM/+ASM1 as sysasm"
+  # gather_asm = false
+
+  ## 多租户：连接CDB$ROOT时逐个切换到已打开的PDB执行所有SQL（含内置采集项），
+  ## 度量值带con_id、pdb_name标签；直接连接PDB时只添加标签
+  ## 需要SET CONTAINER权限
+  # gather_pdbs = false
+  ## 按容器名过滤，支持通配符，CDB$ROOT同样参与过滤
+  # pdb_include = []
+  # pdb_exclude = ["PDB_TEST*"]
+
+  ## RAC：内置采集项改用gv$视图，一个连接（如SCAN地址）即可采集集群所有节点
+  ## SQL返回的inst_id列转为标签，并添加对应的instance_name标签
+  # rac_mode = false
+
+  ## SQL中可使用命名绑定变量，如 WHERE instance_name = :instance_name
+  ## 取值顺序：SQL自身的binds、插件级[inputs.ora.binds]、内置变量
+  ## 内置变量：:hostname 采集端主机名，:now 本次执行时间，:last_run_time 本SQL上次成功执行时间
+  ## 使用:last_run_time可实现增量采集，如 WHERE timestamp > :last_run_time AND timestamp <= :now
+  ## 首次执行时:last_run_time等于:now；执行失败时不推进，下次重新采集
+  ## 设置了time_column的SQL，:last_run_time为已读取行中time_column的最大值（未读取到行时不变），
+  ## 而非上次执行时间，如AWR快照在结束时间之后数秒才提交，不会因此漏采
+  ## 插件级binds见本示例末尾的[inputs.ora.binds]
+  ## 增量采集水位持久化文件，重启后从上次位置继续
+  # state_file = "/var/lib/telegraf/ora_state.json"
+
+  ## 插件每次采集输出ora_internal度量，按SQL（query标签）和数据库统计：
+  ##   duration_ms 执行耗时毫秒数，rows 返回行数，bytes 扫描字节数（估算），errors 错误次数，timeouts 超时次数，
+  ##   truncated 结果超过max_rows被截断
+  ## SQL文件重新加载时同样输出ora_internal度量（file标签）：file_reloads、file_reload_errors
+
+  ## 单条SQL执行失败时单独报告错误（带SQL名称），不影响其它SQL的输出
+  ## 开启后同时输出ora_query_error度量：count、timeout、message字段，标签与ora_internal相同
+  # query_error_metric = false
+
+  ## 熔断：SQL在同一数据库（容器）上连续失败或超时breaker_threshold次后，
+  ## breaker_backoff（默认10m）内不再执行，期间每次采集输出ora_internal的quarantined、quarantined_until字段
+  ## 0表示不熔断
+  # breaker_threshold = 0
+  # breaker_backoff = "10m"
+
+  ## 连接失败（监听重启、故障切换中）时在本次采集内重试connect_retries次，
+  ## 间隔从connect_backoff（默认1s）开始每次加倍，最长30s，0表示不重试
+  ## 开启后每次连接输出ora_internal的connect_retries（重试次数）、connect_errors（最终失败为1）字段
+  # connect_retries = 0
+  # connect_backoff = "1s"
+
+  ## SQL超时后用ALTER SYSTEM KILL SESSION终止插件自己执行该SQL的会话，避免被放弃的SQL继续消耗数据库CPU
+  ## 开启后每条SQL固定在一个会话上执行，执行前按application_info标记会话，需要ALTER SYSTEM权限
+  # kill_on_timeout = false
+
+  ## 会话参数：每个新会话建立后执行ALTER SESSION SET，使查询结果不受数据库默认设置影响
+  ## 数值及TRUE/FALSE原样设置，其它值加单引号，以_开头的隐含参数自动加双引号，见本示例末尾的[inputs.ora.session_params]
+
+  ## 每条SQL执行前通过DBMS_APPLICATION_INFO/DBMS_SESSION标记会话：module为telegraf-ora，action为SQL名称，
+  ## client_identifier为采集端主机名，便于DBA在v$session中识别和管理监控负载，每条SQL多一次往返
+  # application_info = false
+
+  ## SQL设置了retries时可重试的错误号，间隔1秒重试，已读取到行的不重试，避免短暂断连或ORA-01555产生告警
+  # retry_errors = ["ORA-00028", "ORA-01033", "ORA-01089", "ORA-03113", "ORA-03114", "ORA-12541", "ORA-01555"]
+
+  ## 连接池设置，连接在插件生命周期内保持，会话断开时自动重连
+  ## 0表示使用驱动默认值
+  # max_open_connections = 0
+  # max_idle_connections = 0
+  # max_connection_lifetime = "0s"
+
+  ## 度量值名称，替换默认度量值ora及内置度量值（ora_tablespace、ora_internal等）的ora前缀
+  ## SQL自行指定的measurement不受影响
+  # measurement_name = "oracle"
+
+  ## URL标签名称，见本示例末尾的[inputs.ora.url_tags]
+
+  ## 连接后查询一次v$database/v$instance，用dbid、db_unique_name、instance_name、version、database_role
+  ## 标签替代由URL解析的host、port、service、instance标签，重建连接后重新查询，数据库不可用时沿用上次的标识
+  ## 从未成功查询到标识（如启动时数据库即不可用）时不输出ora_up，避免同一数据库出现两组标签
+  # identity_tags = false
+
+  ## 插件级绑定变量
+  # [inputs.ora.binds]
+  #   instance_name = "orcl1"
+
+  ## URL标签名称，可修改host、port、service、instance对应的标签名，设置为空时不输出该标签
+  # [inputs.ora.url_tags]
+  #   host = "db_host"
+  #   port = ""
+
+  ## 会话参数
+  # [inputs.ora.session_params]
+  #   NLS_DATE_FORMAT = "YYYY-MM-DD HH24:MI:SS"
+  #   NLS_NUMERIC_CHARACTERS = ".,"
+  #   TIME_ZONE = "+00:00"
+  #   _query_rewrite_enabled = "FALSE"
+
+  ## 逐个指定的数据库
+  # [[inputs.ora.database]]
+  #   url = "perfstat/perfstat@db3:1521/orcl/orcl3"
+  #   wallet_location = "/etc/telegraf/wallet_db3"
+  #   connect_role = "sysdba"
+  #   username = "perfstat"
+  #   password = "file:/run/secrets/ora_db3_password"
+  #   ## 该数据库所有度量值的静态标签，插件级标签使用telegraf的[inputs.ora.tags]
+  #   [inputs.ora.database.tags]
+  #     env = "prod"
+  #     dc = "fra1"
+
+  ## 直接在配置中定义SQL，与files中的SQL一同执行
+  # [[inputs.ora.query]]
+  #   name = "sessions"
+  #   sql = "SELECT status, count(*) cnt FROM v$session GROUP BY status"
+  #   ## 为0时使用sqlseconds
+  #   timeout = "30s"
+  #   ## 为空时使用ora（或measurement_name）
+  #   measurement = "ora_sessions"
+  #   ## 执行间隔，为0时每次采集都执行
+  #   interval = "10m"
+  #   ## 显式指定作为标签、字段或忽略的列；未指定的列中字符串为标签，数值为字段
+  #   tag_columns = ["inst_id"]
+  #   field_columns = []
+  #   ignore_columns = []
+  #   ## 字段类型转换：int、float、bool、string，指定的列均作为字段
+  #   field_types = {blocks = "int", ratio = "float", status = "bool"}
+  #   ## 累计列转换：delta本周期增量，rate每秒增量
+  #   transforms = {total_waits = "delta", executions = "rate"}
+  #   ## 为空时使用插件级time_format
+  #   time_format = "rfc3339"
+  #   ## 为0时使用插件级max_lob_length
+  #   max_lob_length = 65536
+  #   ## 绑定变量，优先于插件级binds
+  #   binds = {owner = "APP"}
+  #   ## sql为PL/SQL调用时，返回SYS_REFCURSOR的绑定变量名
+  #   cursor = "cur"
+  #   ## 适用的数据库版本范围
+  #   min_version = "12.1"
+  #   max_version = "19"
+  #   ## 适用的数据库角色，|分隔多个
+  #   role = "PRIMARY"
+  #   ## 最多读取的行数
+  #   max_rows = 10000
+  #   ## 静态标签，优先于数据库的tags
+  #   tags = {team = "dba"}
+  #   ## 列值映射，文本不区分大小写，映射后的列作为字段，可再由field_types指定类型
+  #   value_map = {open_mode = "READ WRITE:2|READ ONLY:1|MOUNTED:0"}
+  #   ## NULL处理方式，null_policies按列设置，优先于null_policy
+  #   null_policy = "skip_field"
+  #   null_placeholder = "n/a"
+  #   null_policies = {used_bytes = "zero", owner = "placeholder"}
+  #   ## 行转列：name列的值作为字段名，value列的值作为字段值
+  #   pivot_key = "name"
+  #   pivot_value = "value"
+  #   ## 多行合并为一个度量值，只保留aggregate_tags中的标签列
+  #   aggregate = "sum"
+  #   aggregate_tags = ["tablespace_name"]
+  #   ## 只输出各排序字段最大的前N行
+  #   top = 10
+  #   top_by = ["elapsed_time", "buffer_gets"]
+  #   ## 用snap_time列的值作为度量值时间
+  #   time_column = "snap_time"
+  #   ## 出现retry_errors中的错误时重试
+  #   retries = 2
 `
 
 //说明
 func (o *Ora) Description() string {
-	return "Read metrics from one Oracle Databases."
+	return "Read metrics from one or many Oracle Databases."
 }
 
 //示例输出
@@ -69,85 +841,725 @@ func (o *Ora) SampleConfig() string {
 
 //采集
 func (o *Ora) Gather(acc telegraf.Accumulator) error {
+	//上次采集未结束时跳过本次
+	if o.SkipOverlapping {
+		if !o.TryLock() {
+			n := atomic.AddInt64(&o.skipped, 1)
+			log.Printf("I! ora previous gather still running, skipped")
+			acc.AddFields(o.measurementName("ora_internal"), map[string]interface{}{"gathers_skipped": n}, nil)
+			return nil
+		}
+	} else {
+		o.Lock()
+	}
+	defer o.Unlock()
+
+	if err := o.Init(); err != nil {
+		return err
+	}
+
+	//gather_timeout覆盖整个采集周期，包括连接、标识、权限检查等辅助查询
+	gctx, gcancel := o.gatherContext()
+	defer gcancel()
+	o.setCancel(gcancel)
+	defer o.setCancel(nil)
+
+	//reload_files时检查SQL文件变化，并关闭失效的预编译语句
+	//加载失败的文件沿用上次的内容，错误不影响本次采集
+	if o.ReloadFiles {
+		if err := o.loadQueries(acc); err != nil {
+			acc.AddError(err)
+		}
+		for _, d := range o.dbs {
+			d.pruneStmts(o.queries)
+		}
+	}
+
+	now := time.Now()
+	queries := o.dueQueries(now)
+	for _, d := range o.dbs {
+		d.expireCounters(now)
+	}
+
+	//各数据库并行建立连接并采集，数据库内按max_parallel_queries限制并发
+	//连接错误作为采集错误返回，SQL错误由各SQL单独报告
+	errChan := errchan.New(len(o.dbs))
+	var wg sync.WaitGroup
+	for _, d := range o.dbs {
+		wg.Add(1)
+		go func(d *Database) {
+			defer wg.Done()
+
+			conn, cs, qs, err := o.prepare(gctx, acc, d, queries)
+			if err != nil {
+				errChan.C <- err
+				return
+			}
+
+			//dry_run时只解析SQL，不执行
+			if o.DryRun {
+				o.dryRun(gctx, acc, d, conn)
+				return
+			}
+
+			var jobs []*job
+			for _, c := range cs {
+				for _, q := range qs {
+					if o.quarantined(acc, d, c, q, now) {
+						continue
+					}
+					jobs = append(jobs, &job{d: d, conn: conn, c: c, q: q})
+				}
+			}
+			o.runJobs(gctx, acc, jobs)
+		}(d)
+	}
+	wg.Wait()
+
+	if o.DryRun {
+		return errChan.Error()
+	}
+
+	if err := o.saveState(); err != nil {
+		log.Printf("E! ora save state_file=%s error , %s", o.StateFile, err)
+	}
+
+	return errChan.Error()
+}
+
+//建立连接并确定需要采集的容器及适用于该数据库的SQL
+func (o *Ora) prepare(ctx context.Context, acc telegraf.Accumulator, d *Database, queries []*Query) (*sql.DB, []*container, []*Query, error) {
+	if o.GatherListener {
+		o.probeListener(ctx, acc, d)
+	}
+
+	start := time.Now()
+	conn, err := o.connect(ctx, acc, d)
+	if err != nil {
+		o.heartbeat(ctx, acc, d, nil, 0)
+		return nil, nil, nil, fmt.Errorf("ora connect url=%s error , %s", d.u.redacted(), d.u.mask(err))
+	}
+	elapsed := time.Since(start)
+
+	//重建连接后重新查询标识，查询前沿用上次的标识
+	if o.IdentityTags && (d.identity == nil || d.reidentify) {
+		id, err := o.identity(ctx, conn)
+		if err != nil {
+			o.heartbeat(ctx, acc, d, conn, elapsed)
+			return nil, nil, nil, fmt.Errorf("ora identity host=%s instance=%s error , %s", d.u.host, d.u.instance, err)
+		}
+		d.identity, d.reidentify = id, false
+	}
+	o.heartbeat(ctx, acc, d, conn, elapsed)
+
+	cs, err := o.containers(ctx, conn)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ora containers host=%s instance=%s error , %s", d.u.host, d.u.instance, err)
+	}
+	if len(cs) == 0 {
+		cs = []*container{nil}
+	}
+
+	if d.instances, err = o.instances(ctx, conn); err != nil {
+		return nil, nil, nil, fmt.Errorf("ora instances host=%s instance=%s error , %s", d.u.host, d.u.instance, err)
+	}
+
+	//已查询标识时直接使用其中的版本与角色
+	if d.identity != nil {
+		d.version, d.role = d.identity.version, d.identity.role
+	} else {
+		//版本与角色每个连接池只查询一次，重建连接后重新查询
+		if d.reidentify {
+			d.version, d.role, d.reidentify = "", "", false
+		}
+
+		if len(d.version) == 0 {
+			if d.version, err = o.version(ctx, conn, queries); err != nil {
+				return nil, nil, nil, fmt.Errorf("ora version host=%s instance=%s error , %s", d.u.host, d.u.instance, err)
+			}
+		}
+
+		if len(d.role) == 0 {
+			if d.role, err = o.databaseRole(ctx, conn, queries); err != nil {
+				return nil, nil, nil, fmt.Errorf("ora database_role host=%s instance=%s error , %s", d.u.host, d.u.instance, err)
+			}
+		}
+	}
+
+	//跳过不适用于该数据库的SQL
+	var qs []*Query
+	for _, q := range queries {
+		if d.compatible(q) {
+			qs = append(qs, q)
+		}
+	}
+
+	if o.CheckPrivileges && !d.privileged {
+		o.checkPrivileges(ctx, acc, d, conn)
+		d.privileged = true
+	}
+
+	return conn, cs, qs, nil
+}
+
+//初始化：合并数据库列表，解析URL并校验驱动
+func (o *Ora) Init() error {
+	//Telegraf调用Init后服务型插件还会调用Start，只初始化一次，避免重建连接池与状态
+	if o.initialized {
+		return nil
+	}
+
+	if _, err := o.driverName(); err != nil {
+		return err
+	}
+
+	if err := o.initTLS(); err != nil {
+		return err
+	}
+
+	var dbs []*Database
+	if len(o.Url) > 0 {
+		dbs = append(dbs, &Database{Url: o.Url})
+	}
+	for _, u := range o.Urls {
+		dbs = append(dbs, &Database{Url: u})
+	}
+	dbs = append(dbs, o.Databases...)
+
+	for _, d := range dbs {
+		if len(d.WalletLocation) == 0 {
+			d.WalletLocation = o.WalletLocation
+		}
+		if len(d.ConnectRole) == 0 {
+			d.ConnectRole = o.ConnectRole
+		}
+		if len(d.Username) == 0 {
+			d.Username = o.Username
+		}
+		if len(d.Password) == 0 {
+			d.Password = o.Password
+		}
+		if len(d.VaultPath) == 0 {
+			d.VaultPath = o.VaultPath
+		}
+	}
+
+	if len(dbs) == 0 {
+		return fmt.Errorf("ora no database url configured")
+	}
+
+	var err error
+	if o.sysmetricInclude, err = filter.Compile(o.SysmetricInclude); err != nil {
+		return err
+	}
+	if o.sysmetricExclude, err = filter.Compile(o.SysmetricExclude); err != nil {
+		return err
+	}
+	if o.pdbInclude, err = filter.Compile(o.PdbInclude); err != nil {
+		return err
+	}
+	if o.users, err = filter.Compile(o.GatherUsers); err != nil {
+		return err
+	}
+	var calls []string
+	for _, c := range o.ReadOnlyCalls {
+		calls = append(calls, strings.ToUpper(c))
+	}
+	if o.readOnlyCalls, err = filter.Compile(calls); err != nil {
+		return err
+	}
+	if o.pdbExclude, err = filter.Compile(o.PdbExclude); err != nil {
+		return err
+	}
+
+	if o.hostname, err = os.Hostname(); err != nil {
+		return err
+	}
+
+	if err := o.loadState(); err != nil {
+		return fmt.Errorf("ora load state_file=%s error , %s", o.StateFile, err)
+	}
+
+	if !validTimeFormat(o.TimeFormat) {
+		return fmt.Errorf("ora time_format=%s not support", o.TimeFormat)
+	}
+
+	switch o.NumberFormat {
+	case "", "float", "auto":
+	default:
+		return fmt.Errorf("ora number_format=%s not support", o.NumberFormat)
+	}
+
+	if _, ok := auditQueries[o.GatherAudit]; len(o.GatherAudit) > 0 && !ok {
+		return fmt.Errorf("ora gather_audit=%s not support", o.GatherAudit)
+	}
+	if len(o.StatspackSchema) > 0 && !identifier.MatchString(o.StatspackSchema) {
+		return fmt.Errorf("ora statspack_schema=%s format error", o.StatspackSchema)
+	}
+	if len(o.GoldengateSchema) > 0 && !identifier.MatchString(o.GoldengateSchema) {
+		return fmt.Errorf("ora goldengate_schema=%s format error", o.GoldengateSchema)
+	}
+	if o.sessionStmts, err = sessionStatements(o.SessionParams); err != nil {
+		return fmt.Errorf("ora session_params %s", err)
+	}
+
+	if !validNullPolicy(o.NullPolicy) {
+		return fmt.Errorf("ora null_policy=%s not support", o.NullPolicy)
+	}
+	if o.SqlSeconds <= 0 {
+		o.SqlSeconds = defaultSqlSeconds
+	}
+
+	for _, q := range o.Queries {
+		if err := q.validate(); err != nil {
+			return fmt.Errorf("ora query name=%s %s", q.Name, err)
+		}
+		if err := o.checkReadOnly(q); err != nil {
+			return fmt.Errorf("ora query name=%s %s", q.Name, err)
+		}
+	}
+
+	//生成URL标签，go-ora需从tnsnames.ora取得别名的地址
+	for _, d := range dbs {
+		if err := d.tagUrl(o.Driver == "go-ora"); err != nil {
+			return fmt.Errorf("ora url=%s config error , %s", redactUrl(d.Url), err)
+		}
+
+		if len(d.ConnectRole) == 0 {
+			d.ConnectRole = d.u.role
+		}
+		d.ConnectRole = strings.ToLower(d.ConnectRole)
+		switch d.ConnectRole {
+		case "", "sysdba", "sysoper", "sysasm":
+		default:
+			return fmt.Errorf("ora connect_role=%s not support", d.ConnectRole)
+		}
+	}
+
+	//SQL文件在初始化时解析，采集时只执行SQL
+	if err := o.loadQueries(nil); err != nil {
+		return err
+	}
+
+	o.dbs = dbs
+	o.initialized = true
+	return nil
+}
+
+//合并配置块、内置采集项、默认query pack及SQL文件中的SQL
+func (o *Ora) loadQueries(acc telegraf.Accumulator) error {
+	o.queries = append([]*Query(nil), o.Queries...)
+	o.queries = append(o.queries, o.builtinQueries()...)
+
+	defaults, err := o.defaultQueries()
+	if err != nil {
+		return fmt.Errorf("ora default queries error , %s", err)
+	}
+	o.queries = append(o.queries, defaults...)
+
+	return o.readfiles(acc)
+}
+
+//启动
+func (o *Ora) Start(acc telegraf.Accumulator) error {
 	o.Lock()
 	defer o.Unlock()
 
-	o.sqlmap = make(map[string][]string)
-	err := o.readfiles()
-	if err != nil {
-		return err
+	return o.Init()
+}
+
+//停止，关闭连接池
+func (o *Ora) Stop() {
+	//取消进行中的采集（连接重试等待、SQL），避免等到采集结束才能停止
+	o.cancelMu.Lock()
+	if o.cancel != nil {
+		o.cancel()
+	}
+	o.cancelMu.Unlock()
+
+	o.Lock()
+	defer o.Unlock()
+
+	for _, d := range o.dbs {
+		if d.db != nil {
+			d.pruneStmts(nil)
+			d.db.Close()
+			d.db = nil
+		}
+		o.vaultRevoke(d)
+	}
+
+	if err := o.saveState(); err != nil {
+		log.Printf("E! ora save state_file=%s error , %s", o.StateFile, err)
+	}
+}
+
+//获取连接池，失败时按connect_retries重试，间隔从connect_backoff开始每次加倍，最长maxConnectBackoff
+//新建的连接池先Ping一次，监听重启、故障切换期间不必等到下一个采集周期
+func (o *Ora) connect(ctx context.Context, acc telegraf.Accumulator, d *Database) (*sql.DB, error) {
+	backoff := o.ConnectBackoff.Duration
+	if backoff <= 0 {
+		backoff = defaultConnectBackoff
+	}
+
+	var err error
+	for i := 0; ; i++ {
+		var db *sql.DB
+		if db, err = o.openPool(ctx, d); err == nil {
+			if err = o.ping(ctx, db); err == nil {
+				o.connectTelemetry(acc, d, i, nil)
+				return db, nil
+			}
+			d.pruneStmts(nil)
+			db.Close()
+			d.db = nil
+			d.reidentify = true
+		}
+		if i >= o.ConnectRetries {
+			o.connectTelemetry(acc, d, i, err)
+			return nil, err
+		}
+
+		log.Printf("W! ora url=%s connect failed, retry %d/%d in %s , %s", d.u.redacted(), i+1, o.ConnectRetries, backoff, d.u.mask(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			o.connectTelemetry(acc, d, i, err)
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+//检查连接是否可用，超时为sqlseconds
+func (o *Ora) ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+//获取连接池，连接失效时重建
+func (o *Ora) openPool(ctx context.Context, d *Database) (*sql.DB, error) {
+	//Vault动态凭据更换后重建连接池
+	rotated, err := o.vaultCredentials(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if rotated && d.db != nil {
+		d.pruneStmts(nil)
+		d.db.Close()
+		d.db = nil
+		d.reidentify = true
+	}
+
+	if d.db != nil {
+		if err := o.ping(ctx, d.db); err == nil {
+			return d.db, nil
+		}
+
+		log.Printf("I! ora url=%s connection broken, reconnecting", d.u.redacted())
+		d.pruneStmts(nil)
+		d.db.Close()
+		d.db = nil
+		d.reidentify = true
+	}
+
+	driver, err := o.driverName()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := o.openDB(driver, o.dsn(d))
+	if err != nil {
+		return nil, err
+	}
+
+	if o.MaxOpenConnections > 0 {
+		db.SetMaxOpenConns(o.MaxOpenConnections)
+	}
+	if o.MaxIdleConnections > 0 {
+		db.SetMaxIdleConns(o.MaxIdleConnections)
+	}
+	if o.MaxConnectionLifetime.Duration > 0 {
+		db.SetConnMaxLifetime(o.MaxConnectionLifetime.Duration)
+	}
+
+	//串行模式：连接池只保留一个会话
+	if o.Serialize {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+	}
+
+	d.db = db
+	return d.db, nil
+}
+
+//本次采集需要执行的SQL
+func (o *Ora) dueQueries(now time.Time) []*Query {
+	if o.lastRun == nil {
+		o.lastRun = make(map[string]time.Time)
+	}
+
+	var queries []*Query
+	for _, q := range o.queries {
+		if q.Interval.Duration > 0 {
+			if last, ok := o.lastRun[q.key()]; ok && now.Sub(last) < q.Interval.Duration {
+				continue
+			}
+			o.lastRun[q.key()] = now
+		}
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+//DATE/TIMESTAMP列的输出格式
+func (o *Ora) timeFormat(q *Query) string {
+	if len(q.TimeFormat) > 0 {
+		return q.TimeFormat
 	}
+	return o.TimeFormat
+}
 
-	conn, err := sql.Open("ora", o.Url)
-	if err != nil {
-		return err
+//CLOB/LONG列最大字节数
+func (o *Ora) maxLobLength(q *Query) int {
+	if q.MaxLobLength != 0 {
+		return q.MaxLobLength
+	}
+	if o.MaxLobLength != 0 {
+		return o.MaxLobLength
 	}
-	defer conn.Close()
+	return defaultMaxLobLength
+}
 
-	//生成URL标签
-	o.tagUrl()
+//godror驱动的行预取数与每次获取的行数，为0时使用驱动默认值
+func (o *Ora) fetchOptions(q *Query) []interface{} {
+	if o.Driver == "go-ora" {
+		return nil
+	}
 
-	var ln int
-	for _, v := range o.sqlmap {
-		ln = ln + len(v)
+	fetch, prefetch := q.FetchArraySize, q.PrefetchRows
+	if fetch == 0 {
+		fetch = o.FetchArraySize
+	}
+	if prefetch == 0 {
+		prefetch = o.PrefetchRows
 	}
 
-	errChan := errchan.New(ln)
+	var opts []interface{}
+	if fetch > 0 {
+		opts = append(opts, godror.FetchArraySize(fetch))
+	}
+	if prefetch > 0 {
+		opts = append(opts, godror.PrefetchCount(prefetch))
+	}
+	return opts
+}
 
-	var wg sync.WaitGroup
-	for tag, ss := range o.sqlmap {
-		for _, s := range ss {
-			wg.Add(1)
-			go func(conn *sql.DB, tag string, s string) {
-				defer wg.Done()
-
-				ctx, _ := context.WithTimeout(context.Background(), time.Duration(o.SqlSeconds)*time.Second)
-				select {
-				case <-ctx.Done():
-					errChan.C <- fmt.Errorf("ora gather host=%s instance=%s tag=%s timeout", o.u.host, o.u.instance, tag)
-				case errChan.C <- o.gatherInfo(acc, conn, tag, s):
-				}
+//未配置sqlseconds时单条SQL的最大秒数
+const defaultSqlSeconds = 10
 
-			}(conn, tag, s)
-		}
+//SQL执行超时时间
+func (o *Ora) timeout(q *Query) time.Duration {
+	if q.Timeout.Duration > 0 {
+		return q.Timeout.Duration
 	}
-	wg.Wait()
+	return time.Duration(o.SqlSeconds) * time.Second
+}
 
-	return errChan.Error()
+//可执行查询的连接，*sql.DB或*sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-func (o *Ora) gatherInfo(acc telegraf.Accumulator, conn *sql.DB, tag string, sta string) error {
+func (o *Ora) gatherInfo(ctx context.Context, acc telegraf.Accumulator, d *Database, conn queryer, q *Query, extra map[string]string, st *queryStats) error {
 	var rowData = make(map[string]*interface{})
 	var rowVars []interface{}
+	var tag = q.Name
+
+	now := time.Now()
+	wkey := o.watermarkKey(d, q, extra)
+	last := o.watermark(wkey, now)
+	args, err := o.bindArgs(q, now, last)
+	if err != nil {
+		return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s error , %s", d.u.host, d.u.instance, tag, err)
+	}
+
+	//PL/SQL的REF CURSOR只能在执行它的会话上读取，从连接池取出一个会话，读完结果集后归还
+	if db, ok := conn.(*sql.DB); ok && q.plsql() {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s error , %s", d.u.host, d.u.instance, tag, err)
+		}
+		defer c.Close()
+		conn = c
+	}
 
-	rowset, err := conn.Query(sta)
+	rowset, err := o.query(ctx, d, conn, q, args)
 	if err != nil {
-		return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s error , %s", o.u.host, o.u.instance, tag, err)
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("ora gather host=%s instance=%s tag=%s timeout", d.u.host, d.u.instance, tag)
+		}
+		return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s error , %s", d.u.host, d.u.instance, tag, err)
+	}
+	defer rowset.Close()
+
+	//大对象列与字符列
+	var lobs = make(map[string]bool)
+	var chars = make(map[string]bool)
+	if colTypes, err := rowset.ColumnTypes(); err == nil {
+		for _, ct := range colTypes {
+			switch strings.ToUpper(ct.DatabaseTypeName()) {
+			case "CLOB", "NCLOB", "LONG":
+				lobs[strings.ToLower(ct.Name())] = true
+			case "VARCHAR2", "NVARCHAR2", "VARCHAR", "CHAR", "NCHAR":
+				chars[ct.Name()] = true
+			}
+		}
 	}
 
+	//字符列扫描到sql.RawBytes，复用驱动缓冲区，只为需要输出的列分配字符串
+	//宽表或大结果集逐行读取、逐行输出，内存占用与行数无关
+	var raws = make(map[string]*sql.RawBytes)
 	colNames, err := rowset.Columns()
 	for _, col := range colNames {
 		rowData[col] = new(interface{})
+		if chars[col] {
+			raws[col] = new(sql.RawBytes)
+			rowVars = append(rowVars, raws[col])
+			continue
+		}
 		rowVars = append(rowVars, rowData[col])
 	}
 
+	var pv pivot
+	var maxTime time.Time //time_column的最大值
+	measurement := q.Measurement
+	if len(measurement) == 0 {
+		measurement = o.measurementName("ora")
+	}
+
 	for rowset.Next() {
+		//超过max_rows时停止读取
+		if q.MaxRows > 0 && st.rows >= int64(q.MaxRows) {
+			st.truncated = true
+			log.Printf("W! ora gather host=%s instance=%s tag=%s returned more than max_rows=%d rows, truncated", d.u.host, d.u.instance, tag, q.MaxRows)
+			break
+		}
+
 		if err := rowset.Scan(rowVars...); err != nil {
-			return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s Scan error , %s", o.u.host, o.u.instance, tag, err)
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("ora gather host=%s instance=%s tag=%s timeout", d.u.host, d.u.instance, tag)
+			}
+			return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s Scan error , %s", d.u.host, d.u.instance, tag, err)
+		}
+		for col, raw := range raws {
+			if *raw == nil || hasColumn(q.IgnoreColumns, strings.ToLower(col)) {
+				*rowData[col] = nil
+				continue
+			}
+			*rowData[col] = string(*raw)
 		}
+		st.add(rowData)
 
-		tags, fields, err := o.parseRow(rowData)
+		tags, fields, err := o.parseRow(d, q, rowData, lobs)
+		if err == errSkipRow {
+			continue
+		}
 		if err != nil {
-			return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s parseRow error , %s", o.u.host, o.u.instance, tag, err)
+			return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s parseRow error , %s", d.u.host, d.u.instance, tag, err)
+		}
+		ts := q.rowTime(rowData, tags, fields)
+		if ts.After(maxTime) {
+			maxTime = ts
+		}
+
+		if q.filter != nil && !q.filter(tags) {
+			continue
+		}
+
+		for k, v := range extra {
+			tags[k] = v
+		}
+
+		//静态标签，SQL的tags优先于数据库的tags
+		for k, v := range d.Tags {
+			tags[k] = v
+		}
+		for k, v := range q.Tags {
+			tags[k] = v
+		}
+
+		if o.RacMode {
+			d.tagInstance(tags, fields)
 		}
 
 		tags["func"] = tag
-		acc.AddFields("ora", fields, tags)
+		if q.merged() {
+			q.aggregateTags(tags, colNames)
+			if !pv.add(q, ts, tags, fields) {
+				log.Printf("I! ora gather tag=%s row without pivot_key=%s or pivot_value=%s", tag, q.PivotKey, q.PivotValue)
+			}
+			continue
+		}
+
+		d.delta(q, now, tags, fields)
+		if len(fields) == 0 {
+			continue
+		}
+		if !ts.IsZero() {
+			acc.AddFields(measurement, fields, tags, ts)
+			continue
+		}
+		acc.AddFields(measurement, fields, tags)
+	}
+
+	if err := rowset.Err(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("ora gather host=%s instance=%s tag=%s timeout", d.u.host, d.u.instance, tag)
+		}
+		return fmt.Errorf("ora gatherInfo host=%s instance=%s tag=%s error , %s", d.u.host, d.u.instance, tag, err)
+	}
+
+	//行转列、聚合、top的度量值在读取完所有行后输出
+	var pts []*pivotPoint
+	for _, k := range pv.keys {
+		pt := pv.points[k]
+		d.delta(q, now, pt.tags, pt.fields)
+		if len(pt.fields) > 0 {
+			pts = append(pts, pt)
+		}
+	}
+	if q.Top > 0 && len(q.TopBy) > 0 {
+		pts = topPoints(pts, q.Top, q.TopBy)
+	}
+	for _, pt := range pts {
+		if !pt.time.IsZero() {
+			acc.AddFields(measurement, pt.fields, pt.tags, pt.time)
+			continue
+		}
+		acc.AddFields(measurement, pt.fields, pt.tags)
+	}
+
+	//成功后推进增量水位，设置了time_column时推进到已读取的最大时间，
+	//避免采集时尚未提交的行（如正在写入的AWR快照）因水位已越过其时间而漏采
+	if usesBind(q, "last_run_time") {
+		switch {
+		case len(q.TimeColumn) == 0:
+			o.setWatermark(wkey, now)
+		case !maxTime.IsZero():
+			o.setWatermark(wkey, maxTime)
+		default:
+			o.setWatermark(wkey, last)
+		}
 	}
 	return nil
 }
 
-func (o *Ora) parseRow(rowData map[string]*interface{}) (map[string]string, map[string]interface{}, error) {
+func (o *Ora) parseRow(d *Database, q *Query, rowData map[string]*interface{}, lobs map[string]bool) (map[string]string, map[string]interface{}, error) {
 	var tags = make(map[string]string)
 	var fields = make(map[string]interface{})
 	var err error
@@ -158,7 +1570,102 @@ func (o *Ora) parseRow(rowData map[string]*interface{}) (map[string]string, map[
 		}
 
 		k = strings.ToLower(k)
-		switch val := (*v).(type) {
+		if hasColumn(q.IgnoreColumns, k) {
+			continue
+		}
+
+		val := *v
+		if lobs[k] {
+			if val, err = readLob(val, o.maxLobLength(q)); err != nil {
+				return nil, nil, err
+			}
+		} else if lob, ok := val.(*godror.Lob); ok {
+			bs, err := ioutil.ReadAll(lob)
+			if err != nil {
+				return nil, nil, err
+			}
+			val = string(bs)
+		}
+
+		if isNull(val) {
+			asTag := hasColumn(q.TagColumns, k) || (len(q.fieldType(k)) == 0 && !hasColumn(q.FieldColumns, k))
+			switch o.nullPolicy(q, k) {
+			case nullSkipRow:
+				return nil, nil, errSkipRow
+			case nullSkipField:
+				continue
+			case nullZero:
+				if asTag {
+					tags[k] = "0"
+				} else {
+					fields[k] = int64(0)
+				}
+				continue
+			case nullPlaceholder:
+				if asTag {
+					tags[k] = o.nullPlaceholder(q)
+				} else {
+					fields[k] = o.nullPlaceholder(q)
+				}
+				continue
+			}
+			if val == nil {
+				continue
+			}
+		}
+
+		//value_map映射后的列作为字段
+		mapped, isMapped := q.mapValue(k, val)
+		if isMapped {
+			val = mapped
+		}
+
+		if t := q.fieldType(k); len(t) > 0 {
+			if s, ok := val.(string); ok && (t == "bool" || t == "int") {
+				if b, ok := o.boolText(s); ok {
+					val = b
+				}
+			}
+			if f, ok := coerce(val, t); ok {
+				fields[k] = f
+			}
+			continue
+		}
+		if isMapped {
+			if f, ok := toField(val); ok {
+				fields[k] = f
+			}
+			continue
+		}
+		if o.NumberFormat == "auto" {
+			val = integerNumber(val)
+		}
+		if hasColumn(q.TagColumns, k) {
+			if s, ok := toTag(val); ok {
+				tags[k] = s
+			}
+			continue
+		}
+		if hasColumn(q.FieldColumns, k) {
+			if t, ok := val.(time.Time); ok {
+				if f, ok := formatTime(t, o.timeFormat(q)); ok {
+					fields[k] = f
+					continue
+				}
+			}
+			if f, ok := toField(val); ok {
+				fields[k] = f
+			}
+			continue
+		}
+
+		//大对象列默认作为字段
+		if lobs[k] && val != nil {
+			fields[k] = val
+			continue
+		}
+
+		switch val := val.(type) {
 		case string:
 			if val == "" {
 				val = "NULL"
@@ -168,156 +1675,557 @@ func (o *Ora) parseRow(rowData map[string]*interface{}) (map[string]string, map[
 			tags[k] = string(val)
 		case int64, int32, int, float32, float64:
 			fields[k] = val
-		case ora.OCINum:
+		case godror.Number:
 			n, _ := strconv.ParseFloat(val.String(), 64)
 			fields[k] = n
+		case time.Time:
+			if f, ok := formatTime(val, o.timeFormat(q)); ok {
+				fields[k] = f
+			} else {
+				tags[k] = val.Format("2006-01-02 15:04:05")
+			}
+		case time.Duration:
+			fields[k] = val.Seconds()
 		case bool:
-			tags[k] = fmt.Sprintf("%b", val)
+			tags[k] = strconv.FormatBool(val)
 		default:
 			log.Printf("I! parseRow column=%s type %T not support", k, val)
 		}
+	}
+
+	//添加URL生成标签
+	for k, v := range o.urlTags(d) {
+		tags[k] = v
+	}
+
+	return tags, fields, err
+}
+
+//驱动名称，为空时使用godror
+func (o *Ora) driverName() (string, error) {
+	switch o.Driver {
+	case "", "godror":
+		return "godror", nil
+	case "go-ora":
+		return "oracle", nil
+	default:
+		return "", fmt.Errorf("ora driver=%s not support", o.Driver)
+	}
+}
+
+//校验协议，go-ora使用TLS时生成证书配置
+func (o *Ora) initTLS() error {
+	switch o.Protocol {
+	case "", "tcp":
+		return nil
+	case "tcps":
+	default:
+		return fmt.Errorf("ora protocol=%s not support", o.Protocol)
+	}
+
+	if o.Driver != "go-ora" {
+		if len(o.SSLCA) > 0 || len(o.SSLCert) > 0 || len(o.SSLKey) > 0 {
+			return fmt.Errorf("ora driver=godror tcps certificates must be provided by wallet_location")
+		}
+		return nil
+	}
+
+	tlsConfig, err := internal.GetTLSConfig(o.SSLCert, o.SSLKey, o.SSLCA, o.InsecureSkipVerify)
+	if err != nil {
+		return err
+	}
+	o.tls = tlsConfig
+	return nil
+}
 
-		//添加URL生成标签
-		if len(o.u.host) > 0 {
-			tags["orahost"] = o.u.host
+//驱动连接串，go-ora需转换为oracle://格式
+func (o *Ora) dsn(d *Database) string {
+	if o.Driver != "go-ora" {
+		//EZConnect Plus: tcps://host:port/service/instance?ssl_server_dn_match=off
+		//TNS别名和连接描述符自带协议设置
+		connect := d.u.connect
+		if o.Protocol == "tcps" && len(d.u.descriptor) == 0 {
+			connect = "tcps://" + connect
+			if o.InsecureSkipVerify {
+				connect = connect + "?ssl_server_dn_match=off"
+			}
 		}
 
-		if len(o.u.port) > 0 {
-			tags["oraport"] = o.u.port
+		//使用解析后的用户名密码，密码中可包含特殊字符
+		//wallet认证：由sqlnet.ora所在目录提供凭据
+		dsn := fmt.Sprintf("user=%q password=%q connectString=%q configDir=%q externalAuth=%t",
+			d.u.user, d.u.passwd, connect, d.WalletLocation, len(d.u.user) == 0)
+		if len(d.ConnectRole) > 0 {
+			dsn = dsn + " " + d.ConnectRole + "=1"
 		}
+		return dsn
+	}
 
-		if len(o.u.service) > 0 {
-			tags["oraservice"] = o.u.service
+	var options = make(map[string]string)
+	if len(d.WalletLocation) > 0 {
+		options["WALLET"] = d.WalletLocation
+	}
+	if o.Protocol == "tcps" {
+		options["SSL"] = "true"
+		if o.InsecureSkipVerify {
+			options["SSL VERIFY"] = "false"
 		}
+	}
+	if len(d.ConnectRole) > 0 {
+		options["DBA PRIVILEGE"] = strings.ToUpper(d.ConnectRole)
+	}
+	if o.PrefetchRows > 0 {
+		options["PREFETCH_ROWS"] = strconv.Itoa(o.PrefetchRows)
+	}
+
+	if len(d.u.descriptor) > 0 {
+		return go_ora.BuildJDBC(d.u.user, d.u.passwd, d.u.descriptor, options)
+	}
+
+	port, _ := strconv.Atoi(d.u.port)
+	return go_ora.BuildUrl(d.u.host, port, d.u.service, d.u.user, d.u.passwd, options)
+}
+
+//解析url，生成URL标签
+//username、password优先于URL中的用户名密码
+func (d *Database) tagUrl(strict bool) error {
+	u, err := parseUrl(d.Url, d.WalletLocation, strict)
+	if err != nil {
+		return err
+	}
 
-		if len(o.u.instance) > 0 {
-			tags["orainstance"] = o.u.instance
+	if len(d.Username) > 0 {
+		if u.user, err = resolveSecret(d.Username); err != nil {
+			return fmt.Errorf("username %s", err)
+		}
+	}
+	if len(d.Password) > 0 {
+		if u.passwd, err = resolveSecret(d.Password); err != nil {
+			return fmt.Errorf("password %s", err)
 		}
 	}
 
-	return tags, fields, err
+	if len(u.user) == 0 && len(d.WalletLocation) == 0 && len(d.VaultPath) == 0 {
+		return fmt.Errorf("user not set and no wallet_location or vault_path")
+	}
+
+	d.u = u
+	return nil
 }
 
 //解析url
-// - user/password@host:port/service/instance
-func (o *Ora) tagUrl() {
-	s1 := strings.Split(o.Url, "@")
-	if len(s1) != 2 {
-		log.Fatalf("E! tagUrl url=%s config error", o.Url)
+// - user/password@host[:port][/service[:server_type][/instance]]，见parseEZConnect
+// - /@host:port/service/instance 或 host:port/service/instance （wallet）
+// - user/password@TNS_ALIAS 或 user/password@(DESCRIPTION=...)
+// - 以上格式末尾可带 as sysdba|sysoper|sysasm
+//用户名与密码以第一个/分隔，连接串以最后一个@分隔，密码中可包含/、@和:，
+//密码可用双引号括起
+//strict为true时TNS别名必须能在tnsnames.ora中找到，否则返回错误
+func parseUrl(raw string, wallet string, strict bool) (*url, error) {
+	var user, passwd, connect, role string
+
+	u := strings.TrimSpace(raw)
+	if m := roleSuffix.FindStringSubmatch(u); m != nil {
+		u = strings.TrimSpace(u[:len(u)-len(m[0])])
+		role = strings.ToLower(m[1])
 	}
 
-	s1_0 := strings.Split(s1[0], "/")
-	if len(s1_0) != 2 {
-		log.Fatalf("E! tagUrl url=%s %s config error", o.Url, s1[0])
+	connect = u
+	if i := strings.LastIndex(u, "@"); i >= 0 {
+		connect = u[i+1:]
+		if cred := u[:i]; cred != "/" && len(cred) > 0 {
+			j := strings.Index(cred, "/")
+			if j <= 0 {
+				return nil, fmt.Errorf("user/password format error")
+			}
+
+			user = cred[:j]
+			passwd = cred[j+1:]
+			if len(passwd) > 1 && strings.HasPrefix(passwd, `"`) && strings.HasSuffix(passwd, `"`) {
+				passwd = passwd[1 : len(passwd)-1]
+			}
+		}
 	}
 
-	user := s1_0[0]
-	passwd := s1_0[1]
+	if len(connect) == 0 {
+		return nil, fmt.Errorf("connect string not set")
+	}
 
-	s1_1 := strings.Split(s1[1], ":")
-	if len(s1_1) != 2 {
-		log.Fatalf("E! tagUrl url=%s %s config error", o.Url, s1[1])
+	//连接描述符或TNS别名
+	var descriptor string
+	if strings.HasPrefix(connect, "(") {
+		descriptor = connect
+	} else if !strings.ContainsAny(connect, ":/") {
+		desc, err := resolveTnsAlias(connect, tnsAdminDirs(wallet))
+		if err != nil && strict {
+			return nil, err
+		}
+		if err != nil {
+			log.Printf("I! ora url alias=%s %s, tags derived from alias only", connect, err)
+		}
+		descriptor = desc
 	}
 
-	host := s1_1[0]
+	if len(descriptor) > 0 || !strings.ContainsAny(connect, ":/") {
+		service := descriptorValue(descriptor, "SERVICE_NAME")
+		if len(service) == 0 {
+			service = descriptorValue(descriptor, "SID")
+		}
+		if len(service) == 0 {
+			service = connect
+		}
 
-	s1_1_1 := strings.Split(s1_1[1], "/")
-	if len(s1_1_1) != 3 {
-		log.Fatalf("E! tagUrl url=%s %s config error", o.Url, s1_1[1])
+		return &url{
+			all:        raw,
+			user:       user,
+			passwd:     passwd,
+			connect:    connect,
+			role:       role,
+			descriptor: descriptor,
+			host:       descriptorValue(descriptor, "HOST"),
+			port:       descriptorValue(descriptor, "PORT"),
+			service:    service,
+			instance:   descriptorValue(descriptor, "INSTANCE_NAME"),
+		}, nil
 	}
 
-	port := s1_1_1[0]
-	service := s1_1_1[1]
-	instance := s1_1_1[2]
+	host, port, service, instance, err := parseEZConnect(connect)
+	if err != nil {
+		return nil, err
+	}
 
-	o.u = &url{
-		all:      o.Url,
+	return &url{
+		all:      raw,
 		user:     user,
 		passwd:   passwd,
+		connect:  connect,
+		role:     role,
 		host:     host,
 		port:     port,
 		service:  service,
 		instance: instance,
-	}
+	}, nil
 }
 
-//已不使用
-func (o *Ora) tagUrl2() {
-	defer func() {
-		if p := recover(); p != nil {
-			log.Fatalf("E! tagUrl %s error %v", o.Url, p)
+// 解析EZConnect连接串
+//
+//	[protocol://][//]host[:port][/[service][:server_type][/instance]][?param=value]
+//
+// - host可为[IPv6地址]，port默认1521
+// - server_type为dedicated、shared或pooled，不影响标签
+func parseEZConnect(connect string) (host, port, service, instance string, err error) {
+	s := connect
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	s = strings.TrimPrefix(s, "//")
+	if i := strings.Index(s, "?"); i >= 0 {
+		s = s[:i]
+	}
+
+	var path string
+	if i := strings.Index(s, "/"); i >= 0 {
+		s, path = s[:i], s[i+1:]
+	}
+
+	//主机与端口
+	port = "1521"
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return "", "", "", "", fmt.Errorf("connect string %s format error", connect)
+		}
+		host, s = s[1:end], s[end+1:]
+		if len(s) > 0 && !strings.HasPrefix(s, ":") {
+			return "", "", "", "", fmt.Errorf("connect string %s format error", connect)
+		}
+		s = strings.TrimPrefix(s, ":")
+	} else if i := strings.Index(s, ":"); i >= 0 {
+		host, s = s[:i], s[i+1:]
+	} else {
+		host, s = s, ""
+	}
+	if len(s) > 0 {
+		if _, err := strconv.Atoi(s); err != nil {
+			return "", "", "", "", fmt.Errorf("connect string %s port %s error", connect, s)
+		}
+		port = s
+	}
+	if len(host) == 0 {
+		return "", "", "", "", fmt.Errorf("connect string %s host not set", connect)
+	}
+
+	//服务名、服务器类型与实例名
+	ps := strings.SplitN(path, "/", 2)
+	service = ps[0]
+	if i := strings.Index(service, ":"); i >= 0 {
+		switch strings.ToLower(service[i+1:]) {
+		case "dedicated", "shared", "pooled":
+		default:
+			return "", "", "", "", fmt.Errorf("connect string %s server type %s not support", connect, service[i+1:])
 		}
-	}()
+		service = service[:i]
+	}
+	if len(ps) == 2 {
+		instance = ps[1]
+	}
 
-	user := `([a-zA-z0-9]+)`
-	pass := `([a-zA-z0-9]+)?`
-	ip := `((?:(?:[0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9][0-9]|[0-9]))`
-	port := `([0-9]+)?`
-	service := `([a-zA-Z0-9]+)?`
-	instance := `([a-zA-Z0-9]+)?`
+	return host, port, service, instance, nil
+}
 
-	re := user + "/?" + pass + "@" + ip + ":" + port + "/?" + service + "/?" + instance
-	r := regexp.MustCompile(re)
+//URL末尾的特权角色子句
+var roleSuffix = regexp.MustCompile(`(?i)\s+as\s+(sysdba|sysoper|sysasm)$`)
 
-	matches := r.FindStringSubmatch(o.Url)
+//不带引号的Oracle标识符
+var identifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
 
-	o.u = &url{
-		all:      matches[0],
-		user:     matches[1],
-		passwd:   matches[2],
-		host:     matches[3],
-		port:     matches[4],
-		service:  matches[5],
-		instance: matches[6],
+//读取SQL文件，未变化的文件使用上次解析结果
+func (o *Ora) readfiles(acc telegraf.Accumulator) error {
+	files, err := sqlFiles(o.Files)
+	if err != nil {
+		//重新加载时无法列出文件，沿用上次加载的全部文件
+		if o.files == nil {
+			return err
+		}
+		for _, file := range cachedFiles(o.files) {
+			o.queries = append(o.queries, o.files[file].queries...)
+		}
+		return err
 	}
-}
 
-func (o *Ora) readfiles() error {
-	var errChan = errchan.New(len(o.Files))
+	var errChan = errchan.New(len(files))
+	var cache = make(map[string]*sqlFile)
 
-	for _, file := range o.Files {
-		bs, err := ioutil.ReadFile(file)
+	for _, file := range files {
+		f, err := o.loadFile(acc, file)
+		if f != nil {
+			cache[file] = f
+			o.queries = append(o.queries, f.queries...)
+		}
 		if err != nil {
 			errChan.C <- err
+		}
+	}
+
+	o.files = cache
+	return errChan.Error()
+}
+
+//解析SQL文件内容
+func parseFile(content string) []*Query {
+	var queries []*Query
+
+	//先去掉注释，文件头部之前可有注释块
+	fd, content := parseHeader(stripComments(content))
+	rs := strings.Split(content, ";;")
+
+	for _, r := range rs {
+		if len(strings.TrimSpace(r)) == 0 {
 			continue
 		}
 
-		rs := strings.Split(string(bs), ";;")
+		fs := strings.Split(r, "::")
+		if fs == nil || len(fs) != 2 {
+			log.Printf("I! SQL `%s` format error", r)
+			continue
+		}
 
-		for _, r := range rs {
-			if len(strings.TrimSpace(r)) == 0 {
-				continue
-			}
+		k := strings.TrimSpace(fs[0])
+		v := strings.TrimSpace(fs[1])
+		if len(k) == 0 || len(v) == 0 {
+			continue
+		}
 
-			fs := strings.Split(r, "::")
-			if fs == nil || len(fs) != 2 {
-				log.Printf("I! SQL `%s` format error", r)
-				continue
-			}
+		//注释条目
+		if strings.HasPrefix(k, "#") {
+			continue
+		}
 
-			k := strings.TrimSpace(fs[0])
-			v := strings.TrimSpace(fs[1])
-			if len(k) == 0 || len(v) == 0 {
-				continue
-			}
+		q, err := parseQuery(k, v)
+		if err != nil {
+			log.Printf("I! SQL `%s` %s", k, err)
+			continue
+		}
+
+		queries = append(queries, q)
+	}
+
+	fd.apply(queries)
+	return queries
+}
 
-			o.sqlmap[k] = append(o.sqlmap[k], v)
+//解析k:v|k:v格式的属性值
+func parseAttrMap(k string, v string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, nv := range strings.Split(v, "|") {
+		b := strings.SplitN(nv, ":", 2)
+		if len(b) != 2 {
+			return nil, fmt.Errorf("attribute %s `%s` format error", k, nv)
 		}
+		m[strings.TrimSpace(b[0])] = strings.TrimSpace(b[1])
 	}
+	return m, nil
+}
 
-	//注释条目
-	for k, _ := range o.sqlmap {
-		if strings.HasPrefix(k, "#") {
-			delete(o.sqlmap, k)
+//解析SQL文件条目，名称可带属性 name[key=value,key=value]
+func parseQuery(name string, sta string) (*Query, error) {
+	q := &Query{Name: name, Sql: sta}
+
+	i := strings.Index(name, "[")
+	if i < 0 {
+		return q, nil
+	}
+	if !strings.HasSuffix(name, "]") {
+		return nil, fmt.Errorf("attribute format error")
+	}
+
+	q.Name = strings.TrimSpace(name[:i])
+	for _, attr := range strings.Split(name[i+1:len(name)-1], ",") {
+		kv := strings.SplitN(attr, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("attribute `%s` format error", attr)
+		}
+
+		k := strings.TrimSpace(kv[0])
+		v := strings.TrimSpace(kv[1])
+		switch k {
+		case "measurement":
+			q.Measurement = v
+		case "interval":
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute interval=%s %s", v, err)
+			}
+			q.Interval.Duration = d
+		case "timeout":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				q.Timeout.Duration = time.Duration(n) * time.Second
+				continue
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute timeout=%s %s", v, err)
+			}
+			q.Timeout.Duration = d
+		case "tag_columns":
+			q.TagColumns = strings.Split(v, "|")
+		case "field_columns":
+			q.FieldColumns = strings.Split(v, "|")
+		case "ignore_columns":
+			q.IgnoreColumns = strings.Split(v, "|")
+		case "binds", "tags":
+			m, err := parseAttrMap(k, v)
+			if err != nil {
+				return nil, err
+			}
+			if k == "binds" {
+				q.Binds = m
+			} else {
+				q.Tags = m
+			}
+		case "cursor":
+			q.Cursor = v
+		case "null_policy":
+			if !validNullPolicy(v) {
+				return nil, fmt.Errorf("attribute null_policy=%s not support", v)
+			}
+			q.NullPolicy = v
+		case "null_placeholder":
+			q.NullPlaceholder = v
+		case "pivot_key":
+			q.PivotKey = v
+		case "pivot_value":
+			q.PivotValue = v
+		case "aggregate":
+			if !validAggregate(v) {
+				return nil, fmt.Errorf("attribute aggregate=%s not support", v)
+			}
+			q.Aggregate = v
+		case "aggregate_tags":
+			q.AggregateTags = strings.Split(v, "|")
+		case "top":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute top=%s %s", v, err)
+			}
+			q.Top = n
+		case "top_by":
+			q.TopBy = strings.Split(v, "|")
+		case "time_column":
+			q.TimeColumn = v
+		case "retries":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute retries=%s %s", v, err)
+			}
+			q.Retries = n
+		case "max_rows":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute max_rows=%s %s", v, err)
+			}
+			q.MaxRows = n
+		case "fetch_array_size", "prefetch_rows":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %s=%s %s", k, v, err)
+			}
+			if k == "fetch_array_size" {
+				q.FetchArraySize = n
+			} else {
+				q.PrefetchRows = n
+			}
+		case "role":
+			q.Role = v
+		case "min_version", "max_version":
+			if _, ok := parseVersion(v); !ok {
+				return nil, fmt.Errorf("attribute %s=%s format error", k, v)
+			}
+			if k == "min_version" {
+				q.MinVersion = v
+			} else {
+				q.MaxVersion = v
+			}
+		case "max_lob_length":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute max_lob_length=%s %s", v, err)
+			}
+			q.MaxLobLength = n
+		case "time_format":
+			if !validTimeFormat(v) {
+				return nil, fmt.Errorf("attribute time_format=%s not support", v)
+			}
+			q.TimeFormat = v
+		case "field_types":
+			q.FieldTypes = make(map[string]string)
+			for _, ct := range strings.Split(v, "|") {
+				c := strings.SplitN(ct, ":", 2)
+				if len(c) != 2 || !validFieldType(c[1]) {
+					return nil, fmt.Errorf("attribute field_types `%s` format error", ct)
+				}
+				q.FieldTypes[c[0]] = c[1]
+			}
+		case "transforms":
+			q.Transforms = make(map[string]string)
+			for _, ct := range strings.Split(v, "|") {
+				c := strings.SplitN(ct, ":", 2)
+				if len(c) != 2 || !validTransform(c[1]) {
+					return nil, fmt.Errorf("attribute transforms `%s` format error", ct)
+				}
+				q.Transforms[c[0]] = c[1]
+			}
+		default:
+			return nil, fmt.Errorf("attribute `%s` not support", k)
 		}
 	}
 
-	return errChan.Error()
+	return q, nil
 }
 
 func init() {
 	inputs.Add("ora",
 		func() telegraf.Input {
-			return &Ora{}
+			return &Ora{ReadOnly: true}
 		})
 }
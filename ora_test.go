@@ -0,0 +1,260 @@
+package ora
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseUrl(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		user     string
+		passwd   string
+		connect  string
+		role     string
+		host     string
+		port     string
+		service  string
+		instance string
+	}{
+		{
+			name:     "ezconnect",
+			raw:      "perfstat/perfstat@db1:1521/orcl/orcl1",
+			user:     "perfstat",
+			passwd:   "perfstat",
+			connect:  "db1:1521/orcl/orcl1",
+			host:     "db1",
+			port:     "1521",
+			service:  "orcl",
+			instance: "orcl1",
+		},
+		{
+			name:    "password with / @ and :",
+			raw:     "user/p@ss/w:rd@host:1521/svc",
+			user:    "user",
+			passwd:  "p@ss/w:rd",
+			connect: "host:1521/svc",
+			host:    "host",
+			port:    "1521",
+			service: "svc",
+		},
+		{
+			name:    "quoted password",
+			raw:     `user/"p@ss/w:rd"@host/svc`,
+			user:    "user",
+			passwd:  "p@ss/w:rd",
+			connect: "host/svc",
+			host:    "host",
+			port:    "1521",
+			service: "svc",
+		},
+		{
+			name:    "as sysdba",
+			raw:     "sys/secret@host:1522/svc as sysdba",
+			user:    "sys",
+			passwd:  "secret",
+			connect: "host:1522/svc",
+			role:    "sysdba",
+			host:    "host",
+			port:    "1522",
+			service: "svc",
+		},
+		{
+			name:    "as SYSOPER with extra spaces",
+			raw:     "  sys/secret@host/svc   AS   SYSOPER ",
+			user:    "sys",
+			passwd:  "secret",
+			connect: "host/svc",
+			role:    "sysoper",
+			host:    "host",
+			port:    "1521",
+			service: "svc",
+		},
+		{
+			name:     "wallet without credentials",
+			raw:      "/@host:1521/svc/inst1",
+			connect:  "host:1521/svc/inst1",
+			host:     "host",
+			port:     "1521",
+			service:  "svc",
+			instance: "inst1",
+		},
+		{
+			name:     "no credentials",
+			raw:      "host:1521/svc/inst1",
+			connect:  "host:1521/svc/inst1",
+			host:     "host",
+			port:     "1521",
+			service:  "svc",
+			instance: "inst1",
+		},
+		{
+			name:    "ipv6 host",
+			raw:     "user/pw@[::1]:1521/svc",
+			user:    "user",
+			passwd:  "pw",
+			connect: "[::1]:1521/svc",
+			host:    "::1",
+			port:    "1521",
+			service: "svc",
+		},
+		{
+			name:    "connect descriptor",
+			raw:     "user/pw@(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=db2)(PORT=1523))(CONNECT_DATA=(SERVICE_NAME=svc2)))",
+			user:    "user",
+			passwd:  "pw",
+			connect: "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=db2)(PORT=1523))(CONNECT_DATA=(SERVICE_NAME=svc2)))",
+			host:    "db2",
+			port:    "1523",
+			service: "svc2",
+		},
+	}
+
+	for _, tt := range tests {
+		u, err := parseUrl(tt.raw, "", false)
+		if err != nil {
+			t.Errorf("%s: parseUrl(%q) error %s", tt.name, tt.raw, err)
+			continue
+		}
+		got := []string{u.user, u.passwd, u.connect, u.role, u.host, u.port, u.service, u.instance}
+		want := []string{tt.user, tt.passwd, tt.connect, tt.role, tt.host, tt.port, tt.service, tt.instance}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: parseUrl(%q) = %q, want %q", tt.name, tt.raw, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseUrlWalletAlias(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ora")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tns := "prod_high = (DESCRIPTION=(ADDRESS=(PROTOCOL=tcps)(HOST=adb.example.com)(PORT=1522))(CONNECT_DATA=(SERVICE_NAME=prod_high.adb)))\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "tnsnames.ora"), []byte(tns), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := parseUrl("/@prod_high", dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.user != "" || u.passwd != "" {
+		t.Errorf("wallet url user=%q passwd=%q, want empty", u.user, u.passwd)
+	}
+	if u.connect != "prod_high" || u.host != "adb.example.com" || u.port != "1522" || u.service != "prod_high.adb" {
+		t.Errorf("wallet url connect=%q host=%q port=%q service=%q", u.connect, u.host, u.port, u.service)
+	}
+
+	if _, err := parseUrl("/@prod_low", dir, true); err == nil {
+		t.Errorf("parseUrl unknown alias strict expected error")
+	}
+	u, err = parseUrl("/@prod_low", dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.host != "" || u.service != "prod_low" {
+		t.Errorf("unknown alias host=%q service=%q, want empty host and alias as service", u.host, u.service)
+	}
+}
+
+func TestParseUrlError(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"user/pw@",
+		"nopassword@host/svc",
+		"user/pw@host:port/svc",
+		"user/pw@[::1/svc",
+	} {
+		if _, err := parseUrl(raw, "", false); err == nil {
+			t.Errorf("parseUrl(%q) expected error", raw)
+		}
+	}
+}
+
+func TestRedactUrl(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"perfstat/perfstat@db1:1521/orcl", "perfstat/****@db1:1521/orcl"},
+		{"user/p@ss/w:rd@host:1521/svc", "user/****@host:1521/svc"},
+		{"sys/secret@host/svc as sysdba", "sys/****@host/svc as sysdba"},
+		{"/@prod_high", "/@prod_high"},
+		{"host:1521/svc", "host:1521/svc"},
+	}
+
+	for _, tt := range tests {
+		if got := redactUrl(tt.raw); got != tt.want {
+			t.Errorf("redactUrl(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"entries", "a::SELECT 1 FROM dual;;\nb[measurement=m]::SELECT 2 FROM dual;;", []string{"a", "b"}},
+		{"commented entry", "# old[x=1]::select 'x' from dual;;\nb::SELECT 2 FROM dual;;", []string{"b"}},
+		{"hash name ignored", "#a::SELECT 1 FROM dual;;\nb::SELECT 2 FROM dual;;", []string{"b"}},
+		{"bad attribute skipped", "a[x]::SELECT 1 FROM dual;;\nb::SELECT 2 FROM dual;;", []string{"b"}},
+		{"header after licence block", "/* licence\n * text\n */\n@measurement ora_custom\na::SELECT 1 FROM dual;;", []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, q := range parseFile(tt.content) {
+			got = append(got, q.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseFile names = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	qs := parseFile("/* licence */\n@measurement ora_custom\n@tags team=dba\na::SELECT 1 FROM dual;;\nb[measurement=m,tags=team:x]::SELECT 2 FROM dual;;")
+	if len(qs) != 2 {
+		t.Fatalf("parseFile with header returned %d queries", len(qs))
+	}
+	if qs[0].Measurement != "ora_custom" || qs[0].Tags["team"] != "dba" {
+		t.Errorf("header defaults not applied: measurement=%q tags=%v", qs[0].Measurement, qs[0].Tags)
+	}
+	if qs[1].Measurement != "m" || qs[1].Tags["team"] != "x" {
+		t.Errorf("query attributes overridden by header: measurement=%q tags=%v", qs[1].Measurement, qs[1].Tags)
+	}
+}
+
+func TestParseAttrMap(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+		err  bool
+	}{
+		{"team:dba", map[string]string{"team": "dba"}, false},
+		{"team:dba|tier:gold", map[string]string{"team": "dba", "tier": "gold"}, false},
+		{" team : dba | since:2020-01-01 10:00", map[string]string{"team": "dba", "since": "2020-01-01 10:00"}, false},
+		{"team", nil, true},
+		{"team:dba|", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAttrMap("tags", tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseAttrMap(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAttrMap(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ora
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/toml"
+)
+
+//TOML格式的query pack，每个[[query]]为一条SQL，文件开头的measurement、tags为各条目的默认值：
+//
+//	measurement = "ora_custom"
+//	tags = {team = "dba"}
+//
+//	[[query]]
+//	  name = "sessions"
+//	  measurement = "ora_sessions"
+//	  schedule = "1m"
+//	  timeout = "10s"
+//	  tag_columns = ["status"]
+//	  min_version = "12.1"
+//	  sql = """
+//	SELECT status, COUNT(*) cnt FROM v$session GROUP BY status
+//	"""
+type queryPack struct {
+	Measurement string            `toml:"measurement"`
+	Tags        map[string]string `toml:"tags"`
+	Query       []*packQuery      `toml:"query"`
+}
+
+//query pack条目，字段与[[inputs.ora.query]]相同，schedule为interval的别名
+type packQuery struct {
+	Name            string            `toml:"name"`
+	Sql             string            `toml:"sql"`
+	Measurement     string            `toml:"measurement"`
+	Timeout         internal.Duration `toml:"timeout"`
+	Interval        internal.Duration `toml:"interval"`
+	Schedule        internal.Duration `toml:"schedule"`
+	TagColumns      []string          `toml:"tag_columns"`
+	FieldColumns    []string          `toml:"field_columns"`
+	IgnoreColumns   []string          `toml:"ignore_columns"`
+	FieldTypes      map[string]string `toml:"field_types"`
+	TimeFormat      string            `toml:"time_format"`
+	MaxLobLength    int               `toml:"max_lob_length"`
+	Binds           map[string]string `toml:"binds"`
+	Cursor          string            `toml:"cursor"`
+	FetchArraySize  int               `toml:"fetch_array_size"`
+	PrefetchRows    int               `toml:"prefetch_rows"`
+	MaxRows         int               `toml:"max_rows"`
+	Tags            map[string]string `toml:"tags"`
+	ValueMap        map[string]string `toml:"value_map"`
+	NullPolicy      string            `toml:"null_policy"`
+	NullPlaceholder string            `toml:"null_placeholder"`
+	NullPolicies    map[string]string `toml:"null_policies"`
+	PivotKey        string            `toml:"pivot_key"`
+	PivotValue      string            `toml:"pivot_value"`
+	Aggregate       string            `toml:"aggregate"`
+	AggregateTags   []string          `toml:"aggregate_tags"`
+	Transforms      map[string]string `toml:"transforms"`
+	Top             int               `toml:"top"`
+	TopBy           []string          `toml:"top_by"`
+	TimeColumn      string            `toml:"time_column"`
+	Retries         int               `toml:"retries"`
+	MinVersion      string            `toml:"min_version"`
+	MaxVersion      string            `toml:"max_version"`
+	Role            string            `toml:"role"`
+}
+
+//是否为query pack文件，远程文件忽略?及#之后的部分
+func isPack(file string) bool {
+	if i := strings.IndexAny(file, "?#"); i >= 0 && isRemote(file) {
+		file = file[:i]
+	}
+	return strings.EqualFold(filepath.Ext(file), ".toml")
+}
+
+//解析query pack，任一条目有误时整个文件失败
+func parsePack(bs []byte) ([]*Query, error) {
+	var pack queryPack
+	if err := toml.Unmarshal(bs, &pack); err != nil {
+		return nil, err
+	}
+
+	var queries []*Query
+	for _, p := range pack.Query {
+		q := &Query{
+			Name:            p.Name,
+			Sql:             strings.TrimSpace(p.Sql),
+			Measurement:     p.Measurement,
+			Timeout:         p.Timeout,
+			Interval:        p.Interval,
+			TagColumns:      p.TagColumns,
+			FieldColumns:    p.FieldColumns,
+			IgnoreColumns:   p.IgnoreColumns,
+			FieldTypes:      p.FieldTypes,
+			TimeFormat:      p.TimeFormat,
+			MaxLobLength:    p.MaxLobLength,
+			Binds:           p.Binds,
+			Cursor:          p.Cursor,
+			FetchArraySize:  p.FetchArraySize,
+			PrefetchRows:    p.PrefetchRows,
+			MaxRows:         p.MaxRows,
+			Tags:            p.Tags,
+			ValueMap:        p.ValueMap,
+			NullPolicy:      p.NullPolicy,
+			NullPlaceholder: p.NullPlaceholder,
+			NullPolicies:    p.NullPolicies,
+			PivotKey:        p.PivotKey,
+			PivotValue:      p.PivotValue,
+			Aggregate:       p.Aggregate,
+			AggregateTags:   p.AggregateTags,
+			Transforms:      p.Transforms,
+			Top:             p.Top,
+			TopBy:           p.TopBy,
+			TimeColumn:      p.TimeColumn,
+			Retries:         p.Retries,
+			MinVersion:      p.MinVersion,
+			MaxVersion:      p.MaxVersion,
+			Role:            p.Role,
+		}
+		if p.Schedule.Duration > 0 {
+			q.Interval = p.Schedule
+		}
+
+		if err := q.validate(); err != nil {
+			return nil, fmt.Errorf("query name=%s %s", q.Name, err)
+		}
+		queries = append(queries, q)
+	}
+
+	fd := &fileDefaults{measurement: pack.Measurement, tags: pack.Tags}
+	fd.apply(queries)
+	return queries, nil
+}
@@ -0,0 +1,172 @@
+package ora
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//聚合方式
+const (
+	aggregateSum  = "sum"  //数值字段求和，其它字段取最后一行
+	aggregateLast = "last" //取最后一行
+)
+
+func validAggregate(s string) bool {
+	switch s {
+	case "", aggregateSum, aggregateLast:
+		return true
+	}
+	return false
+}
+
+//行转列：pivot_key列的值作为字段名，pivot_value列的值作为字段值，
+//其余标签相同的行合并为一个度量值，如v$sysstat的NAME/VALUE
+//聚合：只保留aggregate_tags中的标签列，标签相同的行按aggregate合并，
+//如每个RAC节点或每个PDB一行的结果合并为一个度量值
+type pivot struct {
+	points map[string]*pivotPoint
+	keys   []string //按出现顺序输出
+}
+
+type pivotPoint struct {
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time //time_column的值，为零值时使用采集时间
+}
+
+//SQL是否为行转列模式
+func (q *Query) pivoted() bool {
+	return len(q.PivotKey) > 0 && len(q.PivotValue) > 0
+}
+
+//SQL的多行是否在读取完所有行后输出
+func (q *Query) merged() bool {
+	return q.pivoted() || len(q.Aggregate) > 0 || q.Top > 0
+}
+
+//聚合时去掉不在aggregate_tags中的标签列，其它来源的标签（URL、静态标签等）保留
+func (q *Query) aggregateTags(tags map[string]string, cols []string) {
+	if len(q.Aggregate) == 0 {
+		return
+	}
+	for _, col := range cols {
+		col = strings.ToLower(col)
+		if hasColumn(q.AggregateTags, col) {
+			continue
+		}
+		delete(tags, col)
+		//instance_name由inst_id得到
+		if col == "inst_id" && !hasColumn(q.AggregateTags, "instance_name") {
+			delete(tags, "instance_name")
+		}
+	}
+}
+
+//合并一行，时间不同的行不合并，行转列缺少pivot_key或pivot_value时返回false
+func (p *pivot) add(q *Query, t time.Time, tags map[string]string, fields map[string]interface{}) bool {
+	if q.pivoted() {
+		key, value := strings.ToLower(q.PivotKey), strings.ToLower(q.PivotValue)
+
+		name, ok := tags[key]
+		if !ok {
+			v, ok := fields[key]
+			if !ok {
+				return false
+			}
+			if name, ok = toTag(v); !ok {
+				return false
+			}
+		}
+		val, ok := fields[value]
+		if !ok {
+			return false
+		}
+		delete(tags, key)
+		delete(fields, key)
+		delete(fields, value)
+		fields[pivotFieldName(name)] = val
+	}
+
+	if p.points == nil {
+		p.points = make(map[string]*pivotPoint)
+	}
+	k := tagKey(tags)
+	if !t.IsZero() {
+		k += "\x00" + t.String()
+	}
+	pt, ok := p.points[k]
+	if !ok {
+		pt = &pivotPoint{tags: tags, fields: make(map[string]interface{}), time: t}
+		p.points[k] = pt
+		p.keys = append(p.keys, k)
+	}
+	for f, v := range fields {
+		if q.Aggregate == aggregateSum {
+			if old, ok := pt.fields[f]; ok {
+				v = sumValue(old, v)
+			}
+		}
+		pt.fields[f] = v
+	}
+	return true
+}
+
+//求和，整数相加仍为整数，非数值取后者
+func sumValue(a, b interface{}) interface{} {
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			return x + y
+		}
+	}
+	x, ok1 := toFloat(a)
+	y, ok2 := toFloat(b)
+	if !ok1 || !ok2 {
+		return b
+	}
+	return x + y
+}
+
+//按top_by中各字段分别取值最大的前n个（差分后），合并后按原顺序返回
+func topPoints(pts []*pivotPoint, n int, by []string) []*pivotPoint {
+	var keep = make(map[*pivotPoint]bool)
+	for _, f := range by {
+		var ranked []*pivotPoint
+		for _, pt := range pts {
+			if _, ok := toFloat(pt.fields[strings.ToLower(f)]); ok {
+				ranked = append(ranked, pt)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			a, _ := toFloat(ranked[i].fields[strings.ToLower(f)])
+			b, _ := toFloat(ranked[j].fields[strings.ToLower(f)])
+			return a > b
+		})
+		if len(ranked) > n {
+			ranked = ranked[:n]
+		}
+		for _, pt := range ranked {
+			keep[pt] = true
+		}
+	}
+
+	var top []*pivotPoint
+	for _, pt := range pts {
+		if keep[pt] {
+			top = append(top, pt)
+		}
+	}
+	return top
+}
+
+//字段名：小写，字母数字以外的字符替换为_，如"user commits"为user_commits
+func pivotFieldName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,102 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+//SQL引用的动态性能视图、数据字典视图及带schema的对象
+var privilegeObject = regexp.MustCompile(`\b(?:SYS\.)?(?:G?V\$|DBA_|CDB_)[A-Z0-9_$#]+|\b(?:FROM|JOIN) ([A-Z][A-Z0-9_$#]*\.[A-Z][A-Z0-9_$#]*)\b`)
+
+//SQL引用的对象，大写
+func privilegeObjects(q *Query) []string {
+	var objs []string
+	for _, m := range privilegeObject.FindAllStringSubmatch(normalizeSql(q.Sql), -1) {
+		obj := m[0]
+		if len(m[1]) > 0 {
+			obj = m[1]
+		}
+		if s := strings.TrimPrefix(obj, "SYS."); s != obj && privilegeObject.MatchString(s) {
+			obj = s
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+//v$视图实际授权的对象为SYS.V_$xxx
+func grantObject(obj string) string {
+	obj = strings.TrimPrefix(obj, "SYS.")
+	switch {
+	case strings.HasPrefix(obj, "V$"):
+		return "SYS.V_$" + obj[2:]
+	case strings.HasPrefix(obj, "GV$"):
+		return "SYS.GV_$" + obj[3:]
+	case strings.HasPrefix(obj, "DBA_") || strings.HasPrefix(obj, "CDB_"):
+		return "SYS." + obj
+	}
+	return obj
+}
+
+//权限自检：首次连接成功后检查适用于该数据库的SQL引用的对象能否查询，
+//缺少权限的对象输出日志（含需要的GRANT语句及引用的SQL）与ora_privileges度量，避免每个周期只看到ORA-00942
+func (o *Ora) checkPrivileges(ctx context.Context, acc telegraf.Accumulator, d *Database, db *sql.DB) {
+	var used = make(map[string][]string)
+	for _, q := range o.queries {
+		if !d.compatible(q) {
+			continue
+		}
+		for _, obj := range privilegeObjects(q) {
+			if names := used[obj]; len(names) == 0 || names[len(names)-1] != q.Name {
+				used[obj] = append(names, q.Name)
+			}
+		}
+	}
+
+	var objs []string
+	for obj := range used {
+		objs = append(objs, obj)
+	}
+	sort.Strings(objs)
+
+	user := strings.ToUpper(d.u.user)
+	if len(user) == 0 {
+		user = "<user>"
+	}
+
+	missing := 0
+	for _, obj := range objs {
+		qctx, cancel := o.queryContext(ctx)
+		var one int
+		err := db.QueryRowContext(qctx, "SELECT 1 FROM "+obj+" WHERE 1 = 0").Scan(&one)
+		cancel()
+		if err == nil || err == sql.ErrNoRows {
+			continue
+		}
+
+		if !strings.Contains(err.Error(), "ORA-00942") && !strings.Contains(err.Error(), "ORA-01031") {
+			log.Printf("W! ora privileges host=%s instance=%s check %s error , %s", d.u.host, d.u.instance, obj, err)
+			continue
+		}
+
+		missing++
+		log.Printf("E! ora privileges host=%s instance=%s missing SELECT on %s used by %s , GRANT SELECT ON %s TO %s",
+			d.u.host, d.u.instance, obj, strings.Join(used[obj], ","), grantObject(obj), user)
+
+		tags := o.urlTags(d)
+		tags["object"] = obj
+		acc.AddFields(o.measurementName("ora_privileges"), map[string]interface{}{"missing": 1, "queries": strings.Join(used[obj], ",")}, tags)
+	}
+
+	if missing > 0 {
+		log.Printf("E! ora privileges host=%s instance=%s %d of %d objects not accessible", d.u.host, d.u.instance, missing, len(objs))
+	} else {
+		log.Printf("I! ora privileges host=%s instance=%s all %d objects accessible", d.u.host, d.u.instance, len(objs))
+	}
+}
@@ -0,0 +1,60 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+//内置SQL中的RAC占位符：{g}v$xxx 与 {inst_id}
+var (
+	racReplacer    = strings.NewReplacer("{g}v$", "gv$", "{inst_id}", "inst_id, ")
+	nonRacReplacer = strings.NewReplacer("{g}v$", "v$", "{inst_id}", "")
+)
+
+//按rac_mode展开内置SQL占位符
+func (o *Ora) racSql(s string) string {
+	if o.RacMode {
+		return racReplacer.Replace(s)
+	}
+	return nonRacReplacer.Replace(s)
+}
+
+//查询集群各实例编号与实例名
+func (o *Ora) instances(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	if !o.RacMode {
+		return nil, nil
+	}
+
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `SELECT TO_CHAR(inst_id), instance_name FROM gv$instance`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var insts = make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		insts[id] = name
+	}
+	return insts, rows.Err()
+}
+
+//inst_id列转为标签，并添加对应的instance_name标签
+func (d *Database) tagInstance(tags map[string]string, fields map[string]interface{}) {
+	if v, ok := toFloat(fields["inst_id"]); ok {
+		delete(fields, "inst_id")
+		tags["inst_id"] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	if name, ok := d.instances[tags["inst_id"]]; ok {
+		tags["instance_name"] = name
+	}
+}
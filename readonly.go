@@ -0,0 +1,128 @@
+package ora
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+var (
+	//PL/SQL调用：BEGIN 过程名(参数); END;
+	readOnlyCall = regexp.MustCompile(`^BEGIN ([A-Z0-9_$#."]+) ?(\((.*)\))? ?; ?END ?;?$`)
+	//PL/SQL调用的参数只能是绑定变量、字符串、数值或NULL/TRUE/FALSE，可带名称 name =>
+	readOnlyArg = regexp.MustCompile(`^([A-Z0-9_$#"]+ ?=> ?)?(:[A-Z0-9_$#]+|''|[-+]?[0-9.]+|NULL|TRUE|FALSE)$`)
+	//SELECT中会修改数据或加锁的写法
+	readOnlyDeny = regexp.MustCompile(`\bFOR UPDATE\b|^WITH (FUNCTION|PROCEDURE)\b`)
+)
+
+//read_only开启时检查SQL：只允许SELECT/WITH查询，以及对read_only_calls中过程的单个调用
+func (o *Ora) checkReadOnly(q *Query) error {
+	if !o.ReadOnly {
+		return nil
+	}
+
+	s := normalizeSql(q.Sql)
+	if strings.HasPrefix(s, "SELECT ") || strings.HasPrefix(s, "WITH ") || strings.HasPrefix(s, "SELECT(") {
+		if strings.Contains(strings.TrimSuffix(s, ";"), ";") {
+			return fmt.Errorf("read_only refuse multiple statements")
+		}
+		if readOnlyDeny.MatchString(s) {
+			return fmt.Errorf("read_only refuse `%s`", readOnlyDeny.FindString(s))
+		}
+		return nil
+	}
+
+	m := readOnlyCall.FindStringSubmatch(s)
+	if m == nil {
+		return fmt.Errorf("read_only refuse statement, only SELECT or whitelisted PL/SQL call allowed")
+	}
+	call := strings.Replace(m[1], `"`, "", -1)
+	if o.readOnlyCalls == nil || !o.readOnlyCalls.Match(call) {
+		return fmt.Errorf("read_only refuse PL/SQL call %s, not in read_only_calls", call)
+	}
+	if len(strings.TrimSpace(m[3])) > 0 {
+		for _, a := range strings.Split(m[3], ",") {
+			if !readOnlyArg.MatchString(strings.TrimSpace(a)) {
+				return fmt.Errorf("read_only refuse PL/SQL call %s argument `%s`", call, strings.TrimSpace(a))
+			}
+		}
+	}
+	return nil
+}
+
+//过滤SQL文件中不满足read_only的条目
+func (o *Ora) readOnlyQueries(file string, queries []*Query) []*Query {
+	if !o.ReadOnly {
+		return queries
+	}
+
+	var allowed []*Query
+	for _, q := range queries {
+		if err := o.checkReadOnly(q); err != nil {
+			log.Printf("E! ora SQL file %s query name=%s %s", file, q.Name, err)
+			continue
+		}
+		allowed = append(allowed, q)
+	}
+	return allowed
+}
+
+//去掉注释，字符串替换为''、带双引号的标识符替换为""，合并空白并转为大写，用于read_only检查
+//带双引号的过程名因此无法与read_only_calls比较，PL/SQL调用的过程名不能加双引号
+func normalizeSql(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			for end >= 0 && i+end+2 < len(s) && s[i+end+2] == c {
+				//''或""转义
+				next := strings.IndexByte(s[i+end+3:], c)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += next + 2
+			}
+			if end < 0 {
+				i = len(s)
+			} else {
+				i += end + 1
+			}
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteByte(c)
+			b.WriteByte(c)
+			space = false
+			continue
+		case strings.HasPrefix(s[i:], "--"):
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+			c = ' '
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				i = len(s)
+			} else {
+				i += end + 3
+			}
+			c = ' '
+		}
+
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteByte(c)
+	}
+	return strings.ToUpper(b.String())
+}
@@ -0,0 +1,69 @@
+package ora
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/filter"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	o := &Ora{ReadOnly: true}
+	var err error
+	if o.readOnlyCalls, err = filter.Compile([]string{"PKG.*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sql string
+		ok  bool
+	}{
+		{"select 1 from dual", true},
+		{"  -- note\n select 'a;b' from dual", true},
+		{"WITH a AS (SELECT 1 FROM dual) SELECT * FROM a", true},
+		{`SELECT status "UPDATE", cnt "DELETE" FROM t`, true},
+		{`SELECT 1 "FOR UPDATE" FROM dual`, true},
+		{`SELECT 1 "A;B" FROM dual`, true},
+		{"SELECT 'for update' FROM dual", true},
+		{"select * from t for update", false},
+		{"update t set a = 1", false},
+		{"delete from t", false},
+		{"select 1 from dual; delete t", false},
+		{"with function f return number is begin return 1; end; select f from dual", false},
+		{"BEGIN pkg.get(:cur); END;", true},
+		{"begin pkg.get(p => :cur, 'x''y', 3); end;", true},
+		{"BEGIN other.get(:cur); END;", false},
+		{"BEGIN pkg.get(:cur); delete t; END;", false},
+		{"BEGIN pkg.get(:cur, dbms_x.f()); END;", false},
+		{"DECLARE x NUMBER; BEGIN pkg.get(:cur); END;", false},
+	}
+
+	for _, tt := range tests {
+		err := o.checkReadOnly(&Query{Sql: tt.sql})
+		if (err == nil) != tt.ok {
+			t.Errorf("checkReadOnly(%q) = %v, want ok %v", tt.sql, err, tt.ok)
+		}
+	}
+
+	o.ReadOnly = false
+	if err := o.checkReadOnly(&Query{Sql: "delete from t"}); err != nil {
+		t.Errorf("read_only=false checkReadOnly error %s", err)
+	}
+}
+
+func TestNormalizeSql(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"select  a,\n\tb from t", "SELECT A, B FROM T"},
+		{"select 'it''s' from dual", "SELECT '' FROM DUAL"},
+		{`select x "DELETE" from t`, `SELECT X "" FROM T`},
+		{"select /* c */ 1 -- d\nfrom dual", "SELECT 1 FROM DUAL"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeSql(tt.in); got != tt.want {
+			t.Errorf("normalizeSql(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ora
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+//远程文件默认超时与缓存时间
+const (
+	defaultFilesTimeout  = 10 * time.Second
+	defaultFilesCacheTTL = 5 * time.Minute
+)
+
+//是否为远程文件
+func isRemote(file string) bool {
+	return strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
+}
+
+//获取远程文件，缓存未过期或服务端返回304时返回old
+func (o *Ora) fetchFile(file string, old *sqlFile) (*sqlFile, error) {
+	ttl := o.FilesCacheTTL.Duration
+	if ttl <= 0 {
+		ttl = defaultFilesCacheTTL
+	}
+	if old != nil && time.Since(old.fetched) < ttl {
+		return old, nil
+	}
+
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", file, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range o.FilesHeaders {
+		req.Header.Set(k, v)
+	}
+	if old != nil {
+		if len(old.etag) > 0 {
+			req.Header.Set("If-None-Match", old.etag)
+		}
+		if len(old.lastModified) > 0 {
+			req.Header.Set("If-Modified-Since", old.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && old != nil {
+		old.fetched = time.Now()
+		return old, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := parseContent(file, bs)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlFile{
+		queries:      queries,
+		fetched:      time.Now(),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+//获取远程文件用的HTTP客户端，首次使用时创建
+func (o *Ora) httpClient() (*http.Client, error) {
+	if o.client != nil {
+		return o.client, nil
+	}
+
+	tlsConfig, err := internal.GetTLSConfig(o.FilesSSLCert, o.FilesSSLKey, o.FilesSSLCA, o.FilesInsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := o.FilesTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultFilesTimeout
+	}
+
+	o.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: timeout,
+	}
+	return o.client, nil
+}
@@ -0,0 +1,31 @@
+package ora
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+//解析username、password中的引用
+// - env:NAME   环境变量
+// - file:PATH  文件内容，去除首尾空白
+// - 其它       原值
+func resolveSecret(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "env:"):
+		name := strings.TrimPrefix(s, "env:")
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "file:"):
+		bs, err := ioutil.ReadFile(strings.TrimPrefix(s, "file:"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(bs)), nil
+	}
+	return s, nil
+}
@@ -0,0 +1,176 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+//插件会话的module，client_identifier为采集端主机名，action为SQL名称，DBA可据此识别和管理监控会话
+const sessionModule = "telegraf-ora"
+
+//执行SQL的数据库会话
+type session struct {
+	sid    string
+	serial string
+	inst   string
+}
+
+//标记会话：设置client_identifier、module、action，开启kill_on_timeout时返回会话标识供超时后终止
+func (o *Ora) markSession(ctx context.Context, conn *sql.Conn, q *Query) (*session, error) {
+	_, err := conn.ExecContext(ctx, `BEGIN
+  DBMS_SESSION.SET_IDENTIFIER(:id);
+  DBMS_APPLICATION_INFO.SET_MODULE(:module, :action);
+END;`, sql.Named("id", o.hostname), sql.Named("module", sessionModule), sql.Named("action", q.Name))
+	if err != nil {
+		return nil, err
+	}
+	if !o.KillOnTimeout {
+		return nil, nil
+	}
+
+	var s session
+	err = conn.QueryRowContext(ctx, `SELECT TO_CHAR(sid), TO_CHAR(serial#), SYS_CONTEXT('USERENV', 'INSTANCE')
+  FROM v$session
+ WHERE sid = SYS_CONTEXT('USERENV', 'SID')`).Scan(&s.sid, &s.serial, &s.inst)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+//SQL超时后在连接池的另一个会话上终止执行该SQL的会话，避免被放弃的SQL继续消耗数据库CPU
+//需要ALTER SYSTEM权限
+func (o *Ora) killSession(db *sql.DB, d *Database, q *Query, s *session) {
+	ctx, cancel := o.queryContext(context.Background())
+	defer cancel()
+
+	stmt := fmt.Sprintf(`ALTER SYSTEM KILL SESSION '%s,%s,@%s' IMMEDIATE`, s.sid, s.serial, s.inst)
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		log.Printf("E! ora kill session host=%s instance=%s tag=%s sid=%s serial=%s error , %s", d.u.host, d.u.instance, q.Name, s.sid, s.serial, err)
+		return
+	}
+	log.Printf("W! ora killed session host=%s instance=%s tag=%s sid=%s serial=%s after timeout", d.u.host, d.u.instance, q.Name, s.sid, s.serial)
+}
+
+//会话参数名，允许以_开头的隐含参数
+var sessionParam = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$#]*$`)
+
+//session_params生成的ALTER SESSION语句，按参数名排序
+func sessionStatements(params map[string]string) ([]string, error) {
+	var names []string
+	for k := range params {
+		if !sessionParam.MatchString(k) {
+			return nil, fmt.Errorf("%s format error", k)
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var stmts []string
+	for _, k := range names {
+		name, v := k, params[k]
+		if strings.HasPrefix(name, "_") {
+			name = `"` + name + `"`
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil && !strings.EqualFold(v, "TRUE") && !strings.EqualFold(v, "FALSE") {
+			v = "'" + strings.Replace(v, "'", "''", -1) + "'"
+		}
+		stmts = append(stmts, "ALTER SESSION SET "+name+" = "+v)
+	}
+	return stmts, nil
+}
+
+//打开连接池，TLS时使用go-ora的连接器，设置了session_params时每个新会话建立后先执行ALTER SESSION
+func (o *Ora) openDB(name, dsn string) (*sql.DB, error) {
+	var connector driver.Connector
+	switch {
+	case o.tls != nil:
+		c := go_ora.NewConnector(dsn).(*go_ora.OracleConnector)
+		if err := c.WithTLSConfig(o.tls); err != nil {
+			return nil, err
+		}
+		connector = c
+	case len(o.sessionStmts) == 0:
+		return sql.Open(name, dsn)
+	default:
+		db, err := sql.Open(name, dsn)
+		if err != nil {
+			return nil, err
+		}
+		drv := db.Driver()
+		db.Close()
+
+		if dc, ok := drv.(driver.DriverContext); ok {
+			if connector, err = dc.OpenConnector(dsn); err != nil {
+				return nil, err
+			}
+		} else {
+			connector = &dsnConnector{drv: drv, dsn: dsn}
+		}
+	}
+
+	if len(o.sessionStmts) > 0 {
+		connector = &sessionConnector{Connector: connector, stmts: o.sessionStmts}
+	}
+	return sql.OpenDB(connector), nil
+}
+
+//不支持DriverContext的驱动
+type dsnConnector struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+//新会话建立后执行ALTER SESSION，失败时关闭该会话
+type sessionConnector struct {
+	driver.Connector
+	stmts []string
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range c.stmts {
+		if err := execConn(ctx, conn, s); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s error , %s", s, err)
+		}
+	}
+	return conn, nil
+}
+
+//在驱动连接上执行语句
+func execConn(ctx context.Context, conn driver.Conn, s string) error {
+	if ec, ok := conn.(driver.ExecerContext); ok {
+		_, err := ec.ExecContext(ctx, s, nil)
+		return err
+	}
+
+	stmt, err := conn.Prepare(s)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
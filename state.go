@@ -0,0 +1,87 @@
+package ora
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//增量采集水位：SQL成功执行后记录本次执行时间，作为下次执行的:last_run_time
+//配置state_file时持久化，重启后继续增量采集
+
+//水位标识：连接串（不含用户名密码）、SQL及容器标签
+func (o *Ora) watermarkKey(d *Database, q *Query, extra map[string]string) string {
+	return d.u.connect + "\x00" + q.key() + "\x00" + tagKey(extra)
+}
+
+//取上次成功执行时间，没有记录时为now
+func (o *Ora) watermark(key string, now time.Time) time.Time {
+	o.stateMu.Lock()
+	defer o.stateMu.Unlock()
+
+	if t, ok := o.watermarks[key]; ok {
+		return t
+	}
+	return now
+}
+
+//记录成功执行时间
+func (o *Ora) setWatermark(key string, t time.Time) {
+	o.stateMu.Lock()
+	defer o.stateMu.Unlock()
+
+	if o.watermarks == nil {
+		o.watermarks = make(map[string]time.Time)
+	}
+	o.watermarks[key] = t
+}
+
+//从state_file加载水位，文件不存在时忽略
+func (o *Ora) loadState() error {
+	if len(o.StateFile) == 0 {
+		return nil
+	}
+
+	bs, err := ioutil.ReadFile(o.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	o.stateMu.Lock()
+	defer o.stateMu.Unlock()
+	return json.Unmarshal(bs, &o.watermarks)
+}
+
+//保存水位到state_file，先写临时文件再改名
+func (o *Ora) saveState() error {
+	if len(o.StateFile) == 0 {
+		return nil
+	}
+
+	o.stateMu.Lock()
+	bs, err := json.Marshal(o.watermarks)
+	o.stateMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(o.StateFile), ".ora_state")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), o.StateFile)
+}
@@ -0,0 +1,54 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+)
+
+//取缓存的预编译语句，不存在时预编译
+//sql.Stmt在连接池的每个连接上首次使用时预编译，之后复用，减少硬解析
+//预编译需要访问数据库，不持有d.mu，并发预编译同一语句时保留先存入的，关闭重复的
+func (d *Database) stmt(ctx context.Context, db *sql.DB, s string) (*sql.Stmt, error) {
+	d.mu.Lock()
+	st, ok := d.stmts[s]
+	d.mu.Unlock()
+	if ok {
+		return st, nil
+	}
+
+	st, err := db.PrepareContext(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cached, ok := d.stmts[s]; ok {
+		st.Close()
+		return cached, nil
+	}
+	if d.stmts == nil {
+		d.stmts = make(map[string]*sql.Stmt)
+	}
+	d.stmts[s] = st
+	return st, nil
+}
+
+//关闭不再使用的预编译语句，SQL文件重新加载后SQL文本变化的语句随之失效
+//queries为nil时全部关闭
+func (d *Database) pruneStmts(queries []*Query) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var used = make(map[string]bool)
+	for _, q := range queries {
+		used[q.Sql] = true
+	}
+
+	for s, st := range d.stmts {
+		if !used[s] {
+			st.Close()
+			delete(d.stmts, s)
+		}
+	}
+}
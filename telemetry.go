@@ -0,0 +1,115 @@
+package ora
+
+import (
+	"context"
+	"time"
+
+	"github.com/godror/godror"
+	"github.com/influxdata/telegraf"
+)
+
+//单条SQL一次执行的统计
+type queryStats struct {
+	rows      int64
+	bytes     int64 //扫描到的列值字节数（估算）
+	truncated bool  //超过max_rows被截断
+}
+
+//累计一行数据
+func (st *queryStats) add(row map[string]*interface{}) {
+	st.rows++
+	for _, v := range row {
+		st.bytes += valueSize(*v)
+	}
+}
+
+//列值的估算字节数，大对象不计入
+func valueSize(v interface{}) int64 {
+	switch n := v.(type) {
+	case string:
+		return int64(len(n))
+	case []byte:
+		return int64(len(n))
+	case godror.Number:
+		return int64(len(n))
+	case nil, *godror.Lob:
+		return 0
+	}
+	return 8
+}
+
+//输出ora_internal度量：每条SQL在每个数据库（容器）上的执行耗时、行数、字节数、错误与超时次数
+func (o *Ora) telemetry(ctx context.Context, acc telegraf.Accumulator, d *Database, c *container, q *Query, elapsed time.Duration, st *queryStats, err error) {
+	var errors, timeouts int64
+	if err != nil {
+		errors = 1
+		if ctx.Err() == context.DeadlineExceeded {
+			timeouts = 1
+		}
+	}
+
+	var truncated int64
+	if st.truncated {
+		truncated = 1
+	}
+
+	acc.AddFields(o.measurementName("ora_internal"), map[string]interface{}{
+		"duration_ms": float64(elapsed) / float64(time.Millisecond),
+		"rows":        st.rows,
+		"bytes":       st.bytes,
+		"errors":      errors,
+		"timeouts":    timeouts,
+		"truncated":   truncated,
+	}, o.queryTags(d, c, q))
+}
+
+//连接重试
+const (
+	defaultConnectBackoff = time.Second
+	maxConnectBackoff     = 30 * time.Second
+)
+
+//输出ora_internal度量：本次采集建立连接的重试次数及是否最终失败，未开启connect_retries时不输出
+func (o *Ora) connectTelemetry(acc telegraf.Accumulator, d *Database, retries int, err error) {
+	if o.ConnectRetries <= 0 {
+		return
+	}
+
+	var errors int64
+	if err != nil {
+		errors = 1
+	}
+	acc.AddFields(o.measurementName("ora_internal"), map[string]interface{}{
+		"connect_retries": int64(retries),
+		"connect_errors":  errors,
+	}, o.urlTags(d))
+}
+
+//SQL执行失败：通过acc.AddError报告，不影响其它SQL的结果
+//开启query_error_metric时同时输出ora_query_error度量
+func (o *Ora) queryError(ctx context.Context, acc telegraf.Accumulator, d *Database, c *container, q *Query, err error) {
+	acc.AddError(err)
+	if !o.QueryErrorMetric {
+		return
+	}
+
+	var timeout bool
+	if ctx.Err() == context.DeadlineExceeded {
+		timeout = true
+	}
+	acc.AddFields(o.measurementName("ora_query_error"), map[string]interface{}{
+		"count":   int64(1),
+		"timeout": timeout,
+		"message": d.u.mask(err),
+	}, o.queryTags(d, c, q))
+}
+
+//ora_internal、ora_query_error的标签：SQL名称与数据库
+func (o *Ora) queryTags(d *Database, c *container, q *Query) map[string]string {
+	tags := o.urlTags(d)
+	tags["query"] = q.Name
+	if c != nil {
+		tags["pdb_name"] = c.name
+	}
+	return tags
+}
@@ -0,0 +1,111 @@
+package ora
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//tnsnames.ora所在目录：wallet目录、$TNS_ADMIN、$ORACLE_HOME/network/admin
+func tnsAdminDirs(wallet string) []string {
+	var dirs []string
+	if len(wallet) > 0 {
+		dirs = append(dirs, wallet)
+	}
+	if d := os.Getenv("TNS_ADMIN"); len(d) > 0 {
+		dirs = append(dirs, d)
+	}
+	if d := os.Getenv("ORACLE_HOME"); len(d) > 0 {
+		dirs = append(dirs, filepath.Join(d, "network", "admin"))
+	}
+	return dirs
+}
+
+//在tnsnames.ora中查找别名对应的连接描述符
+func resolveTnsAlias(alias string, dirs []string) (string, error) {
+	for _, dir := range dirs {
+		bs, err := ioutil.ReadFile(filepath.Join(dir, "tnsnames.ora"))
+		if err != nil {
+			continue
+		}
+
+		if desc, ok := parseTnsnames(string(bs))[strings.ToUpper(alias)]; ok {
+			return desc, nil
+		}
+	}
+
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("tns alias %s not found in tnsnames.ora, wallet_location, TNS_ADMIN and ORACLE_HOME not set", alias)
+	}
+	return "", fmt.Errorf("tns alias %s not found in tnsnames.ora under %s", alias, strings.Join(dirs, ","))
+}
+
+//解析tnsnames.ora内容，返回 大写别名=>连接描述符
+// - ALIAS1[,ALIAS2] = (DESCRIPTION=...)
+func parseTnsnames(content string) map[string]string {
+	var entries = make(map[string]string)
+
+	//去掉#注释
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	s := strings.Join(lines, "\n")
+
+	for len(s) > 0 {
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			break
+		}
+		names := s[:eq]
+		s = s[eq+1:]
+
+		//括号配对取出描述符
+		start := strings.Index(s, "(")
+		if start < 0 {
+			break
+		}
+		depth, end := 0, -1
+		for i := start; i < len(s); i++ {
+			if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		desc := s[start : end+1]
+		s = s[end+1:]
+
+		for _, name := range strings.Split(names, ",") {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if len(name) > 0 {
+				entries[name] = desc
+			}
+		}
+	}
+
+	return entries
+}
+
+//取出连接描述符中第一个 (KEY=value) 的值
+func descriptorValue(desc string, key string) string {
+	r := regexp.MustCompile(`(?i)\(\s*` + key + `\s*=\s*([^()\s]+)\s*\)`)
+	m := r.FindStringSubmatch(desc)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
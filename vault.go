@@ -0,0 +1,164 @@
+package ora
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+//Vault数据库密钥引擎签发的动态凭据
+type vaultLease struct {
+	id        string
+	renewable bool
+	duration  time.Duration
+	expire    time.Time
+}
+
+//Vault接口返回
+type vaultSecret struct {
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+	Data          struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"data"`
+}
+
+//剩余时间不足租期的1/3时续租，无法续租时重新申请，租期为0表示不过期
+func (l *vaultLease) expiring() bool {
+	return l.duration > 0 && time.Until(l.expire) < l.duration/3
+}
+
+//建立连接前检查动态凭据，返回true表示凭据已更换，需要重建连接池
+func (o *Ora) vaultCredentials(ctx context.Context, d *Database) (bool, error) {
+	if len(d.VaultPath) == 0 {
+		return false, nil
+	}
+
+	if d.lease != nil && !d.lease.expiring() {
+		return false, nil
+	}
+
+	if d.lease != nil && d.lease.renewable {
+		var s vaultSecret
+		body := map[string]interface{}{"lease_id": d.lease.id, "increment": int64(d.lease.duration / time.Second)}
+		err := o.vaultRequest(ctx, "PUT", "sys/leases/renew", body, &s)
+		if err == nil && s.LeaseDuration > 0 {
+			d.lease.expire = time.Now().Add(time.Duration(s.LeaseDuration) * time.Second)
+			if !d.lease.expiring() {
+				return false, nil
+			}
+		}
+		if err != nil {
+			log.Printf("I! ora vault renew lease path=%s error , %s, requesting new credentials", d.VaultPath, err)
+		}
+	}
+
+	var s vaultSecret
+	if err := o.vaultRequest(ctx, "GET", d.VaultPath, nil, &s); err != nil {
+		return false, fmt.Errorf("vault path=%s %s", d.VaultPath, err)
+	}
+	if len(s.Data.Username) == 0 {
+		return false, fmt.Errorf("vault path=%s returned no username", d.VaultPath)
+	}
+
+	d.u.user = s.Data.Username
+	d.u.passwd = s.Data.Password
+	d.lease = &vaultLease{
+		id:        s.LeaseID,
+		renewable: s.Renewable,
+		duration:  time.Duration(s.LeaseDuration) * time.Second,
+		expire:    time.Now().Add(time.Duration(s.LeaseDuration) * time.Second),
+	}
+	log.Printf("I! ora vault issued credentials user=%s for url=%s, lease %s", d.u.user, d.u.redacted(), d.lease.duration)
+	return true, nil
+}
+
+//停止时撤销动态凭据，此时gather的ctx已取消，只受客户端超时限制
+func (o *Ora) vaultRevoke(d *Database) {
+	if d.lease == nil || len(d.lease.id) == 0 {
+		return
+	}
+
+	if err := o.vaultRequest(context.Background(), "PUT", "sys/leases/revoke", map[string]interface{}{"lease_id": d.lease.id}, nil); err != nil {
+		log.Printf("E! ora vault revoke lease path=%s error , %s", d.VaultPath, err)
+	}
+	d.lease = nil
+}
+
+//调用Vault HTTP API，ctx取消时中断请求
+func (o *Ora) vaultRequest(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	//各数据库并行连接，客户端只创建一次
+	o.vaultMu.Lock()
+	if o.vaultClient == nil {
+		tlsConfig, err := internal.GetTLSConfig("", "", o.VaultSSLCA, o.VaultInsecureSkipVerify)
+		if err != nil {
+			o.vaultMu.Unlock()
+			return err
+		}
+		o.vaultClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: 10 * time.Second,
+		}
+	}
+	client := o.vaultClient
+	o.vaultMu.Unlock()
+
+	addr := o.VaultAddress
+	if len(addr) == 0 {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if len(addr) == 0 {
+		return fmt.Errorf("vault_address not set")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if len(o.VaultToken) > 0 {
+		var err error
+		if token, err = resolveSecret(o.VaultToken); err != nil {
+			return fmt.Errorf("vault_token %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if len(o.VaultNamespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", o.VaultNamespace)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,112 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+//数据库版本，只在有SQL设置了min_version或max_version时查询
+func (o *Ora) version(ctx context.Context, db *sql.DB, queries []*Query) (string, error) {
+	var need bool
+	for _, q := range queries {
+		if len(q.MinVersion) > 0 || len(q.MaxVersion) > 0 {
+			need = true
+			break
+		}
+	}
+	if !need {
+		return "", nil
+	}
+
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+
+	var version string
+	err := db.QueryRowContext(ctx, `SELECT version FROM v$instance`).Scan(&version)
+	return version, err
+}
+
+//数据库角色，只在有SQL设置了role时查询
+//PRIMARY、PHYSICAL STANDBY、LOGICAL STANDBY、SNAPSHOT STANDBY等
+func (o *Ora) databaseRole(ctx context.Context, db *sql.DB, queries []*Query) (string, error) {
+	var need bool
+	for _, q := range queries {
+		if len(q.Role) > 0 {
+			need = true
+			break
+		}
+	}
+	if !need {
+		return "", nil
+	}
+
+	ctx, cancel := o.queryContext(ctx)
+	defer cancel()
+
+	var role string
+	err := db.QueryRowContext(ctx, `SELECT database_role FROM v$database`).Scan(&role)
+	return role, err
+}
+
+//SQL是否适用于该数据库的版本与角色
+func (d *Database) compatible(q *Query) bool {
+	if len(q.Role) > 0 && !matchRole(d.role, q.Role) {
+		return false
+	}
+	if len(q.MinVersion) > 0 && compareVersion(d.version, q.MinVersion) < 0 {
+		return false
+	}
+	if len(q.MaxVersion) > 0 && compareVersion(d.version, q.MaxVersion) > 0 {
+		return false
+	}
+	return true
+}
+
+//角色是否匹配，role可用|分隔多个角色，不区分大小写
+func matchRole(dbRole, role string) bool {
+	for _, r := range strings.Split(role, "|") {
+		if strings.EqualFold(strings.TrimSpace(r), dbRole) {
+			return true
+		}
+	}
+	return false
+}
+
+//比较版本号，只比较v2给出的位数，如12.1.0.2与12.1相等
+func compareVersion(v1, v2 string) int {
+	n1, _ := parseVersion(v1)
+	n2, _ := parseVersion(v2)
+	for i, n := range n2 {
+		var m int
+		if i < len(n1) {
+			m = n1[i]
+		}
+		switch {
+		case m < n:
+			return -1
+		case m > n:
+			return 1
+		}
+	}
+	return 0
+}
+
+//解析版本号，支持12.1.0.2及11g、12c、19c写法
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimRight(strings.ToLower(strings.TrimSpace(v)), "gc")
+	if len(v) == 0 {
+		return nil, false
+	}
+
+	var ns []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, false
+		}
+		ns = append(ns, n)
+	}
+	return ns, true
+}
@@ -0,0 +1,117 @@
+package ora
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+//一条SQL在一个数据库（容器）上的执行任务
+type job struct {
+	d    *Database
+	conn *sql.DB
+	c    *container
+	q    *Query
+}
+
+//辅助查询（版本、容器、标识等）的上下文，超时为sqlseconds
+func (o *Ora) queryContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, time.Duration(o.SqlSeconds)*time.Second)
+}
+
+//记录进行中采集的取消函数，供Stop使用
+func (o *Ora) setCancel(cancel context.CancelFunc) {
+	o.cancelMu.Lock()
+	o.cancel = cancel
+	o.cancelMu.Unlock()
+}
+
+//整个采集周期的上下文，设置gather_timeout时带截止时间
+func (o *Ora) gatherContext() (context.Context, context.CancelFunc) {
+	if o.GatherTimeout.Duration > 0 {
+		return context.WithTimeout(context.Background(), o.GatherTimeout.Duration)
+	}
+	return context.WithCancel(context.Background())
+}
+
+//执行同一数据库的任务，最多max_parallel_queries个同时执行，serialize时逐个执行
+func (o *Ora) runJobs(ctx context.Context, acc telegraf.Accumulator, jobs []*job) {
+	n := o.MaxParallelQueries
+	if o.Serialize {
+		n = 1
+	}
+	if n <= 0 || n > len(jobs) {
+		n = len(jobs)
+	}
+
+	ch := make(chan *job)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range ch {
+				o.runJob(ctx, acc, j)
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		ch <- j
+	}
+	close(ch)
+	wg.Wait()
+}
+
+//可重试错误的默认列表：会话被终止、数据库启动/关闭中、连接中断、监听不可用、快照过旧
+var defaultRetryErrors = []string{"ORA-00028", "ORA-01033", "ORA-01089", "ORA-03113", "ORA-03114", "ORA-12541", "ORA-01555"}
+
+//两次重试的间隔
+const retryInterval = time.Second
+
+//错误是否可重试，retry_errors为空时使用defaultRetryErrors
+func (o *Ora) retryable(err error) bool {
+	codes := o.RetryErrors
+	if len(codes) == 0 {
+		codes = defaultRetryErrors
+	}
+	for _, code := range codes {
+		if strings.Contains(err.Error(), code) {
+			return true
+		}
+	}
+	return false
+}
+
+//执行单个任务，输出执行统计并报告错误
+func (o *Ora) runJob(parent context.Context, acc telegraf.Accumulator, j *job) {
+	//超时或超过gather_timeout后由驱动取消数据库中的调用
+	ctx, cancel := context.WithTimeout(parent, o.timeout(j.q))
+	defer cancel()
+
+	var st queryStats
+	start := time.Now()
+	err := o.gatherContainer(ctx, acc, j.d, j.conn, j.c, j.q, &st)
+	//可重试的错误：未读取到任何行时重试，已输出的行不重复输出
+	for i := 0; i < j.q.Retries && err != nil && st.rows == 0 && o.retryable(err); i++ {
+		log.Printf("W! ora gather host=%s instance=%s tag=%s retry %d/%d , %s", j.d.u.host, j.d.u.instance, j.q.Name, i+1, j.q.Retries, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		err = o.gatherContainer(ctx, acc, j.d, j.conn, j.c, j.q, &st)
+	}
+	o.telemetry(ctx, acc, j.d, j.c, j.q, time.Since(start), &st, err)
+	o.recordResult(j.d, j.c, j.q, err)
+	if err != nil {
+		o.queryError(ctx, acc, j.d, j.c, j.q, err)
+	}
+}